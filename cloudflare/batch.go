@@ -0,0 +1,57 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+)
+
+// 批次請求中修改單一記錄的部分欄位
+type BatchPatch struct {
+	ID string `json:"id"`
+	RecordPatch
+}
+
+type batchRequest struct {
+	Patches []BatchPatch `json:"patches"`
+}
+
+type batchResponse struct {
+	Result struct {
+		Patches []DNSRecord `json:"patches"`
+	} `json:"result"`
+	Success bool       `json:"success"`
+	Errors  []APIError `json:"errors"`
+}
+
+// 以單一請求修改同一區域的多筆記錄，Cloudflare 會在同一交易中套用（全部成功或全部失敗）。
+// 回傳修改後的記錄，順序與請求相同
+func (c *CloudflareClient) BatchPatchDNSRecords(ctx context.Context, zoneID string, patches []BatchPatch) ([]DNSRecord, error) {
+	req, err := c.newRequest(ctx, "POST", "/zones/"+zoneID+"/dns_records/batch", nil, batchRequest{Patches: patches})
+	if err != nil {
+		return nil, err
+	}
+
+	if verbose {
+		fmt.Printf("🔧 批次修改 %d 筆記錄 (區域 %s)\n", len(patches), zoneID)
+		for _, patch := range patches {
+			fmt.Printf("   - %s%s\n", patch.ID, describePatch(patch.RecordPatch))
+		}
+	}
+
+	var result batchResponse
+	if _, err := c.do(req, &result); err != nil {
+		return nil, fmt.Errorf("Cloudflare %w", err)
+	}
+	if len(result.Result.Patches) != len(patches) {
+		return nil, fmt.Errorf("批次修改的響應記錄數量不符: 預期 %d，實際 %d", len(patches), len(result.Result.Patches))
+	}
+
+	records := result.Result.Patches
+	for i := range records {
+		if records[i].ZoneID == "" {
+			records[i].ZoneID = zoneID
+		}
+	}
+
+	return records, nil
+}
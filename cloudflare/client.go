@@ -1,19 +1,25 @@
 package cloudflare
 
 import (
-	"bytes"
 	"cfddns/config"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 )
 
 type CloudflareClient struct {
-	apiToken string
-	client   *http.Client
+	apiToken  string
+	baseURL   string
+	userAgent string
+	client    *http.Client
+	zones     *zoneCache
+	retry     retryPolicy
+	limiter   *rateLimiter
 }
 
 type Zone struct {
@@ -22,35 +28,47 @@ type Zone struct {
 	Status string `json:"status"`
 }
 
-type ZoneResponse struct {
-	Result  []Zone     `json:"result"`
+type SingleRecordResponse struct {
+	Result  DNSRecord  `json:"result"`
 	Success bool       `json:"success"`
 	Errors  []APIError `json:"errors"`
 }
 
-type DNSRecordResponse struct {
-	Result  []DNSRecord `json:"result"`
-	Success bool        `json:"success"`
-	Errors  []APIError  `json:"errors"`
+type DNSRecord struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Content  string   `json:"content"`
+	Proxied  bool     `json:"proxied"`
+	TTL      int      `json:"ttl"`
+	ZoneID   string   `json:"zone_id"`
+	ZoneName string   `json:"zone_name,omitempty"`
+	Comment  string   `json:"comment,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
 }
 
-type DNSRecord struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	Content  string `json:"content"`
-	Proxied  bool   `json:"proxied"`
-	TTL      int    `json:"ttl"`
-	ZoneID   string `json:"zone_id"`
-	ZoneName string `json:"zone_name,omitempty"`
+// 修改記錄的部分欄位，未設置的欄位保持不變
+type RecordPatch struct {
+	Content *string  `json:"content,omitempty"`
+	Proxied *bool    `json:"proxied,omitempty"`
+	TTL     *int     `json:"ttl,omitempty"`
+	Comment *string  `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"` // 設置時取代全部標籤
+}
+
+// 是否沒有需要修改的欄位
+func (p RecordPatch) Empty() bool {
+	return p.Content == nil && p.Proxied == nil && p.TTL == nil && p.Comment == nil && p.Tags == nil
 }
 
 type UpdateRecordRequest struct {
-	Type    string `json:"type"`
-	Name    string `json:"name"`
-	Content string `json:"content"`
-	Proxied bool   `json:"proxied"`
-	TTL     int    `json:"ttl"`
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Content string   `json:"content"`
+	Proxied bool     `json:"proxied"`
+	TTL     int      `json:"ttl"`
+	Comment string   `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
 }
 
 type APIResponse struct {
@@ -73,151 +91,64 @@ type TokenVerifyResponse struct {
 	Errors []APIError `json:"errors"`
 }
 
-var verbose bool
-
-func NewClient(cfg *config.CloudflareConfig) *CloudflareClient {
-	return &CloudflareClient{
-		apiToken: strings.TrimSpace(cfg.APIToken),
-		client:   &http.Client{Timeout: 30 * time.Second},
-	}
-}
-
-func SetVerbose(v bool) {
-	verbose = v
-}
+// 記錄不存在時回傳的錯誤，可用 errors.Is 判斷
+var ErrRecordNotFound = errors.New("未找到DNS記錄")
 
-// 自動發現 Zone ID
-func (c *CloudflareClient) AutoDiscoverZoneID(dnsRecordName string) (string, error) {
-	// 從 DNS 記錄名稱中提取域名
-	targetZoneName := extractZoneNameFromDNS(dnsRecordName)
-	if targetZoneName == "" {
-		return "", fmt.Errorf("無法從記錄名稱中提取域名: %s", dnsRecordName)
-	}
+// 同名稱有多筆記錄且無法判斷要管理哪一筆時回傳的錯誤
+var ErrAmbiguousRecord = errors.New("DNS記錄不唯一")
 
-	if verbose {
-		fmt.Printf("🔍 自動發現 Zone ID for: %s\n", targetZoneName)
-	}
+var verbose bool
 
-	zones, err := c.GetZones()
+func NewClient(cfg *config.CloudflareConfig, opts ...Option) (*CloudflareClient, error) {
+	httpClient, err := newHTTPClient(cfg)
 	if err != nil {
-		return "", fmt.Errorf("獲取區域列錶失敗: %w", err)
-	}
-
-	// 查找匹配的域名
-	var matchedZone *Zone
-	for _, zone := range zones {
-		if strings.EqualFold(zone.Name, targetZoneName) {
-			matchedZone = &zone
-			break
-		}
+		return nil, err
 	}
 
-	if matchedZone == nil {
-		// 嘗試部分匹配
-		for _, zone := range zones {
-			if strings.HasSuffix(strings.ToLower(dnsRecordName), strings.ToLower("."+zone.Name)) {
-				matchedZone = &zone
-				break
-			}
-		}
+	userAgent := strings.TrimSpace(cfg.UserAgent)
+	if userAgent == "" {
+		userAgent = defaultUserAgent
 	}
 
-	if matchedZone == nil {
-		return "", fmt.Errorf("未找到域名 %s 對應的區域，可用區域: %v",
-			targetZoneName, getZoneNames(zones))
+	apiToken := strings.TrimSpace(cfg.APIToken)
+	c := &CloudflareClient{
+		apiToken:  apiToken,
+		baseURL:   normalizeBaseURL(cfg.BaseURL),
+		userAgent: userAgent,
+		client:    httpClient,
+		zones:     newZoneCache(time.Duration(cfg.ZoneCacheTTL) * time.Second),
+		retry:     newRetryPolicy(cfg.Retry),
+		limiter:   limiterFor(apiToken, cfg.RateLimit),
 	}
-
-	if verbose {
-		fmt.Printf("✅ 發現 Zone ID: %s for %s\n", matchedZone.ID, matchedZone.Name)
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	return matchedZone.ID, nil
+	return c, nil
 }
 
-// 獲取用戶可訪問的所有區域
-func (c *CloudflareClient) GetZones() ([]Zone, error) {
-	url := "https://api.cloudflare.com/client/v4/zones?per_page=1000"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("創建請求失敗: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	if verbose {
-		fmt.Printf("🔍 獲取區域列錶...\n")
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("網絡請求失敗: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("讀取響應失敗: %w", err)
-	}
-
-	if verbose && resp.StatusCode != 200 {
-		fmt.Printf("⚠️  狀態碼: %d, 響應: %s\n", resp.StatusCode, string(body))
-	}
-
-	var result ZoneResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("解析 JSON 失敗: %w", err)
-	}
-
-	if !result.Success {
-		if len(result.Errors) > 0 {
-			return nil, fmt.Errorf("API 錯誤: %v", result.Errors)
-		}
-		return nil, fmt.Errorf("API 調用失敗，狀態碼: %d", resp.StatusCode)
-	}
-
-	return result.Result, nil
+func SetVerbose(v bool) {
+	verbose = v
 }
 
 // 測試 API Token
-func (c *CloudflareClient) TestConnection() error {
-	url := "https://api.cloudflare.com/client/v4/user/tokens/verify"
-
-	req, err := http.NewRequest("GET", url, nil)
+func (c *CloudflareClient) TestConnection(ctx context.Context) error {
+	req, err := c.newRequest(ctx, "GET", "/user/tokens/verify", nil, nil)
 	if err != nil {
-		return fmt.Errorf("創建請求失敗: %w", err)
+		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Content-Type", "application/json")
-
 	fmt.Printf("🧪 測試 Cloudflare API Token...\n")
 	fmt.Printf("   Token: %s...\n", maskString(c.apiToken, 10))
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("網絡請求失敗: %w", err)
+	if c.baseURL != DefaultBaseURL {
+		fmt.Printf("   API 端點: %s\n", c.baseURL)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("讀取響應失敗: %w", err)
-	}
-
-	fmt.Printf("   狀態碼: %d\n", resp.StatusCode)
 
 	var result TokenVerifyResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("解析 JSON 失敗: %w", err)
-	}
-
-	if !result.Success {
-		if len(result.Errors) > 0 {
-			return fmt.Errorf("Token 驗證失敗: %v", result.Errors)
-		}
-		return fmt.Errorf("Token 驗證失敗")
+	statusCode, err := c.do(req, &result)
+	fmt.Printf("   狀態碼: %d\n", statusCode)
+	if err != nil {
+		return fmt.Errorf("Token 驗證失敗: %w", err)
 	}
 
 	fmt.Printf("✅ Token 驗證成功!\n")
@@ -226,7 +157,7 @@ func (c *CloudflareClient) TestConnection() error {
 	fmt.Printf("   狀態: %s\n", result.Result.Status)
 
 	// 獲取區域列錶來顯示權限
-	zones, err := c.GetZones()
+	zones, err := c.GetZones(ctx)
 	if err != nil {
 		fmt.Printf("⚠️  獲取區域列錶失敗: %v\n", err)
 	} else {
@@ -245,168 +176,205 @@ func (c *CloudflareClient) TestConnection() error {
 }
 
 // 獲取特定 DNS 記錄
-func (c *CloudflareClient) GetDNSRecord(recordName, recordType string) (*DNSRecord, error) {
-	zoneID, err := c.AutoDiscoverZoneID(recordName)
+func (c *CloudflareClient) GetDNSRecord(ctx context.Context, record *config.DNSRecord) (*DNSRecord, error) {
+	recordName, recordType := record.Name, record.Type
+	zoneID, err := c.ResolveZoneID(ctx, record)
 	if err != nil {
 		return nil, err
 	}
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records?type=%s&name=%s",
-		zoneID, recordType, recordName)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("創建請求失敗: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Content-Type", "application/json")
-
 	if verbose {
 		fmt.Printf("🔍 查找記錄: %s %s\n", recordName, recordType)
 	}
 
-	resp, err := c.client.Do(req)
+	records, err := c.ListDNSRecords(ctx, zoneID, recordName, recordType)
+	if IsNotFound(err) && record.ZoneID == "" {
+		// 快取的 Zone ID 可能已失效（例如區域被刪除後重新加入），清除區域快取後重新查詢
+		c.ResetZoneCache()
+		if zoneID, err = c.ResolveZoneID(ctx, record); err != nil {
+			return nil, err
+		}
+		records, err = c.ListDNSRecords(ctx, zoneID, recordName, recordType)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("網絡請求失敗: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("讀取響應失敗: %w", err)
+	// 同名稱的多筆記錄（輪詢、多線路）以 match 選擇要管理的記錄
+	records = matchRecords(records, record.Match)
+	switch {
+	case len(records) == 0 && record.Match.IsZero():
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, recordName)
+	case len(records) == 0:
+		return nil, fmt.Errorf("%w: %s (%s)", ErrRecordNotFound, recordName, record.Match)
+	case len(records) > 1 && record.Match.IsZero():
+		return nil, fmt.Errorf("%w: %s %s 有 %d 筆記錄，請設置 match 選擇要管理的記錄",
+			ErrAmbiguousRecord, recordName, recordType, len(records))
+	case len(records) > 1:
+		return nil, fmt.Errorf("%w: %s %s 有 %d 筆記錄符合 %s",
+			ErrAmbiguousRecord, recordName, recordType, len(records), record.Match)
 	}
 
-	var result DNSRecordResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("解析 JSON 失敗: %w", err)
+	// 部分 API 版本的響應不包含 zone_id
+	if records[0].ZoneID == "" {
+		records[0].ZoneID = zoneID
 	}
 
-	if !result.Success {
-		if len(result.Errors) > 0 {
-			return nil, fmt.Errorf("API 錯誤: %v", result.Errors)
+	return &records[0], nil
+}
+
+// 篩選符合條件的記錄，未設置條件時回傳全部記錄。
+// 備註需完全相同（忽略擁有者標記），避免 "site-a" 誤選到 "site-ab" 的記錄
+func matchRecords(records []DNSRecord, match config.RecordMatch) []DNSRecord {
+	if match.IsZero() {
+		return records
+	}
+
+	var matched []DNSRecord
+	for _, record := range records {
+		if match.Comment != "" && config.StripOwnerComment(record.Comment) != strings.TrimSpace(match.Comment) {
+			continue
 		}
-		return nil, fmt.Errorf("API 調用失敗")
+		if match.Tag != "" && !slices.Contains(record.Tags, match.Tag) {
+			continue
+		}
+		matched = append(matched, record)
 	}
+	return matched
+}
 
-	if len(result.Result) == 0 {
-		return nil, fmt.Errorf("未找到DNS記錄: %s", recordName)
+// 列出區域中的 DNS 記錄，名稱和類型為空時不篩選
+func (c *CloudflareClient) ListDNSRecords(ctx context.Context, zoneID, recordName, recordType string) ([]DNSRecord, error) {
+	query := url.Values{}
+	if recordName != "" {
+		query.Set("name", recordName)
+	}
+	if recordType != "" {
+		query.Set("type", recordType)
 	}
 
-	return &result.Result[0], nil
+	return collect(paginate[DNSRecord](ctx, c, "/zones/"+zoneID+"/dns_records", query, dnsRecordsPerPage))
 }
 
-// 獲取 DNS 記錄的當前 IP
-func (c *CloudflareClient) GetDNSRecordIP(recordName, recordType string) (string, error) {
-	record, err := c.GetDNSRecord(recordName, recordType)
+// 根據記錄 ID 修改記錄的部分欄位，回傳修改後的記錄
+func (c *CloudflareClient) PatchDNSRecord(ctx context.Context, zoneID, recordID string, patch RecordPatch) (*DNSRecord, error) {
+	req, err := c.newRequest(ctx, "PATCH", "/zones/"+zoneID+"/dns_records/"+recordID, nil, patch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return record.Content, nil
-}
 
-// 獲取 DNS 記錄 ID
-func (c *CloudflareClient) GetDNSRecordID(recordName, recordType string) (string, error) {
-	record, err := c.GetDNSRecord(recordName, recordType)
-	if err != nil {
-		return "", err
+	if verbose {
+		fmt.Printf("🔧 修改記錄: %s%s\n", recordID, describePatch(patch))
+	}
+
+	var result SingleRecordResponse
+	if _, err := c.do(req, &result); err != nil {
+		return nil, fmt.Errorf("Cloudflare %w", err)
+	}
+	if result.Result.ZoneID == "" {
+		result.Result.ZoneID = zoneID
 	}
-	return record.ID, nil
+
+	return &result.Result, nil
 }
 
-// 更新 DNS 記錄
-func (c *CloudflareClient) UpdateDNSRecord(recordID string, record *config.DNSRecord, ip string) error {
-	zoneID, err := c.AutoDiscoverZoneID(record.Name)
-	if err != nil {
-		return err
+// 修改內容的描述（詳細輸出使用）
+func describePatch(patch RecordPatch) string {
+	var parts []string
+	if patch.Content != nil {
+		parts = append(parts, "content="+*patch.Content)
+	}
+	if patch.Proxied != nil {
+		parts = append(parts, fmt.Sprintf("proxied=%v", *patch.Proxied))
+	}
+	if patch.TTL != nil {
+		parts = append(parts, "TTL="+describeTTL(*patch.TTL))
+	}
+	if patch.Comment != nil {
+		parts = append(parts, fmt.Sprintf("comment=%q", *patch.Comment))
 	}
+	if patch.Tags != nil {
+		parts = append(parts, "tags="+strings.Join(patch.Tags, ","))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s",
-		zoneID, recordID)
+// 判斷錯誤是否為資源不存在（例如暫存的記錄 ID 已被刪除）
+func IsNotFound(err error) bool {
+	var reqErr *RequestError
+	return errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound
+}
 
-	// 根據 TTL 值設置正確的 API 參數
-	ttl := record.TTL
-	if ttl == 1 {
-		// TTL=1 表示自動
-		ttl = 1
-	} else if ttl < 60 {
-		// 如果設置了小於 60 的值，強制設為 60（Cloudflare 最小值）
-		ttl = 60
-	} else if ttl > 86400 {
-		// 如果設置了大於 86400 的值，強制設為 86400（Cloudflare 最大值）
-		ttl = 86400
+// 創建 DNS 記錄，可附加備註和標籤
+func (c *CloudflareClient) CreateDNSRecord(ctx context.Context, record *config.DNSRecord, ip, comment string, tags []string) (*DNSRecord, error) {
+	zoneID, err := c.ResolveZoneID(ctx, record)
+	if err != nil {
+		return nil, err
 	}
 
-	updateReq := UpdateRecordRequest{
+	ttl := EffectiveTTL(record)
+
+	createReq := UpdateRecordRequest{
 		Type:    record.Type,
 		Name:    record.Name,
 		Content: ip,
 		Proxied: record.Proxied,
 		TTL:     ttl,
+		Comment: comment,
+		Tags:    tags,
 	}
 
-	jsonData, err := json.Marshal(updateReq)
+	req, err := c.newRequest(ctx, "POST", "/zones/"+zoneID+"/dns_records", nil, createReq)
 	if err != nil {
-		return fmt.Errorf("序列化請求失敗: %w", err)
-	}
-
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("創建請求失敗: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiToken)
-	req.Header.Set("Content-Type", "application/json")
-
 	if verbose {
-		ttlDescription := "自動"
-		if ttl > 1 {
-			ttlDescription = fmt.Sprintf("%d 秒", ttl)
-		}
-		fmt.Printf("🔧 更新記錄: %s -> %s (TTL: %s)\n", record.Name, ip, ttlDescription)
+		fmt.Printf("🆕 創建記錄: %s %s -> %s (TTL: %s)\n", record.Name, record.Type, ip, describeTTL(ttl))
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("網絡請求失敗: %w", err)
+	var result SingleRecordResponse
+	if _, err := c.do(req, &result); err != nil {
+		return nil, fmt.Errorf("Cloudflare %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("讀取響應失敗: %w", err)
+	if result.Result.ZoneID == "" {
+		result.Result.ZoneID = zoneID
 	}
 
-	var result APIResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("解析 JSON 失敗: %w", err)
-	}
+	return &result.Result, nil
+}
 
-	if !result.Success {
-		if len(result.Errors) > 0 {
-			return fmt.Errorf("Cloudflare API錯誤: %s", result.Errors[0].Message)
-		}
-		return fmt.Errorf("Cloudflare API調用失敗")
+// 記錄在 Cloudflare 中實際生效的 TTL：開啟代理的記錄 TTL 固定為自動
+func EffectiveTTL(record *config.DNSRecord) int {
+	if record.Proxied {
+		return 1
 	}
-
-	return nil
+	return NormalizeTTL(record.TTL)
 }
 
-// 輔助函數
-func extractZoneNameFromDNS(dnsName string) string {
-	parts := strings.Split(dnsName, ".")
-	if len(parts) >= 2 {
-		return parts[len(parts)-2] + "." + parts[len(parts)-1]
+// 根據 TTL 值設置正確的 API 參數
+func NormalizeTTL(ttl int) int {
+	if ttl == 1 {
+		// TTL=1 表示自動
+		return 1
+	} else if ttl < 60 {
+		// 如果設置了小於 60 的值，強制設為 60（Cloudflare 最小值）
+		return 60
+	} else if ttl > 86400 {
+		// 如果設置了大於 86400 的值，強制設為 86400（Cloudflare 最大值）
+		return 86400
 	}
-	return dnsName
+	return ttl
 }
 
-func getZoneNames(zones []Zone) []string {
-	var names []string
-	for _, zone := range zones {
-		names = append(names, zone.Name)
+func describeTTL(ttl int) string {
+	if ttl > 1 {
+		return fmt.Sprintf("%d 秒", ttl)
 	}
-	return names
+	return "自動"
 }
 
 func maskString(s string, showLen int) string {
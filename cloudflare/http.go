@@ -0,0 +1,213 @@
+package cloudflare
+
+import (
+	"bytes"
+	"cfddns/config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// 預設的 Cloudflare API 端點
+const DefaultBaseURL = "https://api.cloudflare.com/client/v4"
+
+var defaultUserAgent = "cfddns"
+
+// 設置預設的 User-Agent（通常包含版本號）
+func SetDefaultUserAgent(ua string) {
+	if ua != "" {
+		defaultUserAgent = ua
+	}
+}
+
+// 客戶端選項
+type Option func(*CloudflareClient)
+
+// 使用自定義的 HTTP 客戶端（忽略配置中的 proxy / ca_file / timeout）
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *CloudflareClient) {
+		c.client = client
+	}
+}
+
+// 使用自定義的 HTTP Transport，保留配置中的超時設置
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *CloudflareClient) {
+		c.client.Transport = transport
+	}
+}
+
+// API 請求錯誤
+type RequestError struct {
+	StatusCode int
+	Errors     []APIError
+}
+
+func (e *RequestError) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("API 錯誤: %v", e.Errors)
+	}
+	return fmt.Sprintf("API 調用失敗，狀態碼: %d", e.StatusCode)
+}
+
+// 根據配置建立 HTTP 客戶端
+func newHTTPClient(cfg *config.CloudflareConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址無效: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("讀取 CA 憑證失敗: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 憑證中沒有有效的 PEM 憑證: %s", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: transport,
+	}, nil
+}
+
+// 建立 API 請求，path 為相對於 API 端點的路徑
+func (c *CloudflareClient) newRequest(ctx context.Context, method, path string, query url.Values, body any) (*http.Request, error) {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("序列化請求失敗: %w", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("創建請求失敗: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+
+	return req, nil
+}
+
+// 發送請求並解析 JSON 響應，回傳 HTTP 狀態碼；
+// 遇到限流、伺服器錯誤或網絡錯誤時按重試策略退避重試
+func (c *CloudflareClient) do(req *http.Request, out any) (int, error) {
+	for attempt := 1; ; attempt++ {
+		statusCode, retryAfter, err := c.doOnce(req, out)
+		if err == nil || req.Context().Err() != nil || !c.retry.shouldRetry(req.Method, statusCode, err, attempt) {
+			return statusCode, err
+		}
+
+		delay := c.retry.backoff(attempt, retryAfter)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			// 等待時間超過剩餘時間（例如很長的 Retry-After），直接回報錯誤而不是等到超時
+			return statusCode, fmt.Errorf("%w（需等待 %s 後重試，超過剩餘時間）", err, delay.Round(time.Second))
+		}
+		if verbose {
+			fmt.Printf("🔁 請求失敗 (%v)，%s 後重試 (%d/%d)\n",
+				err, delay.Round(time.Millisecond), attempt+1, c.retry.maxAttempts)
+		}
+		if err := SleepContext(req.Context(), delay); err != nil {
+			return statusCode, err
+		}
+
+		// 重新設置請求內容
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return statusCode, fmt.Errorf("重設請求內容失敗: %w", bodyErr)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// 發送一次請求，回傳狀態碼和伺服器要求的重試等待時間
+func (c *CloudflareClient) doOnce(req *http.Request, out any) (int, time.Duration, error) {
+	if err := c.limiter.wait(req.Context()); err != nil {
+		return 0, 0, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("網絡請求失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	retryAfter := parseRetryAfter(resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, retryAfter, fmt.Errorf("讀取響應失敗: %w", err)
+	}
+
+	if verbose && resp.StatusCode != http.StatusOK {
+		fmt.Printf("⚠️  狀態碼: %d, 響應: %s\n", resp.StatusCode, string(body))
+	}
+
+	var result APIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode >= 400 {
+			// 閘道錯誤等情況可能回傳非 JSON 內容
+			return resp.StatusCode, retryAfter, &RequestError{StatusCode: resp.StatusCode}
+		}
+		return resp.StatusCode, retryAfter, fmt.Errorf("解析 JSON 失敗: %w", err)
+	}
+
+	if !result.Success {
+		return resp.StatusCode, retryAfter, &RequestError{StatusCode: resp.StatusCode, Errors: result.Errors}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp.StatusCode, retryAfter, fmt.Errorf("解析 JSON 失敗: %w", err)
+		}
+	}
+
+	return resp.StatusCode, retryAfter, nil
+}
+
+// 規範化 API 端點地址
+func normalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	return baseURL
+}
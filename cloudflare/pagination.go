@@ -0,0 +1,94 @@
+package cloudflare
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// 每頁數量（Cloudflare 對不同端點有不同上限，區域列錶最多 50）
+const (
+	zonesPerPage      = 50
+	dnsRecordsPerPage = 100
+)
+
+// 分頁信息
+type ResultInfo struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
+}
+
+// 列錶類 API 的響應
+type listResponse[T any] struct {
+	Result     []T        `json:"result"`
+	ResultInfo ResultInfo `json:"result_info"`
+}
+
+// 逐頁請求列錶類 API，依序產出每一筆結果；出錯時產出錯誤並停止
+func paginate[T any](ctx context.Context, c *CloudflareClient, path string, query url.Values, perPage int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		params := url.Values{}
+		for key, values := range query {
+			params[key] = values
+		}
+		params.Set("per_page", strconv.Itoa(perPage))
+
+		for page := 1; ; page++ {
+			params.Set("page", strconv.Itoa(page))
+
+			req, err := c.newRequest(ctx, "GET", path, params, nil)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var result listResponse[T]
+			if _, err := c.do(req, &result); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range result.Result {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if !hasNextPage(result.ResultInfo, page, len(result.Result), perPage) {
+				return
+			}
+		}
+	}
+}
+
+// 判斷是否還有下一頁；沒有 result_info 時以本頁是否填滿判斷
+func hasNextPage(info ResultInfo, page, count, perPage int) bool {
+	if count == 0 {
+		return false
+	}
+	if info.TotalPages > 0 {
+		return page < info.TotalPages
+	}
+	if info.TotalCount > 0 {
+		return page*perPage < info.TotalCount
+	}
+	return count >= perPage
+}
+
+// 收集所有分頁結果
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,168 @@
+package cloudflare
+
+import (
+	"cfddns/config"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 重試策略
+type retryPolicy struct {
+	maxAttempts int           // 最大嘗試次數（含首次）
+	baseDelay   time.Duration // 初始退避時間
+	maxDelay    time.Duration // 最大退避時間
+}
+
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	return retryPolicy{
+		maxAttempts: max(cfg.MaxAttempts, 1),
+		baseDelay:   time.Duration(cfg.BaseDelay) * time.Second,
+		maxDelay:    time.Duration(cfg.MaxDelay) * time.Second,
+	}
+}
+
+// 判斷請求是否可以重試：
+// 429 一定未被處理，任何方法都可重試；5xx 和網絡錯誤隻重試冪等請求，避免 POST 重複創建記錄
+func (p retryPolicy) shouldRetry(method string, statusCode int, err error, attempt int) bool {
+	if attempt >= p.maxAttempts {
+		return false
+	}
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if method == http.MethodPost {
+		return false
+	}
+	if statusCode >= 500 {
+		return true
+	}
+	var reqErr *RequestError
+	return statusCode == 0 && err != nil && !errors.As(err, &reqErr)
+}
+
+// 計算第 attempt 次失敗後的等待時間：指數退避加隨機抖動，伺服器指定 Retry-After 時優先使用
+// （不受 max_delay 限制，提前重試只會再次被限流；超過剩餘時間時由呼叫方放棄重試）
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	delay := p.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// 在 [delay/2, delay] 之間隨機，避免多個實例同時重試
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// 解析 Retry-After 標頭（秒數或 HTTP 日期）
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// 請求配額：在滑動時間窗口內限制請求數（Cloudflare 預設每個 Token 5 分鐘 1200 次）
+type rateLimiter struct {
+	mu       sync.Mutex
+	requests int
+	window   time.Duration
+	sent     []time.Time // 窗口內已發送請求的時間
+}
+
+// 同一個 Token 在進程內共用配額
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*rateLimiter)
+)
+
+func limiterFor(token string, cfg config.RateLimitConfig) *rateLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	limiter, ok := limiters[token]
+	if !ok {
+		limiter = &rateLimiter{}
+		limiters[token] = limiter
+	}
+
+	limiter.mu.Lock()
+	limiter.requests = cfg.Requests
+	limiter.window = time.Duration(cfg.Window) * time.Second
+	limiter.mu.Unlock()
+
+	return limiter
+}
+
+// 預留一次請求配額，回傳需要等待的時間
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.requests <= 0 || l.window <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.sent) && !l.sent[i].After(cutoff) {
+		i++
+	}
+	l.sent = l.sent[i:]
+
+	if len(l.sent) < l.requests {
+		l.sent = append(l.sent, now)
+		return 0
+	}
+
+	// 配額已用完，等到最早的請求移出窗口
+	return l.sent[0].Add(l.window).Sub(now)
+}
+
+// 等待直到有可用配額或 context 結束
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if verbose {
+			fmt.Printf("⏳ 已達請求配額，等待 %s\n", delay.Round(time.Second))
+		}
+		if err := SleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// 等待指定時間，context 結束時提前返回
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
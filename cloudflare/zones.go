@@ -0,0 +1,234 @@
+package cloudflare
+
+import (
+	"cfddns/config"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 區域快取，避免每次操作記錄都重新列出所有區域
+type zoneCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	zones     []Zone              // 完整區域列錶
+	fetchedAt time.Time           // 區域列錶的獲取時間
+	byName    map[string]zoneItem // 區域名稱 -> Zone ID
+	fetchMu   sync.Mutex          // 並發查詢時只發送一次區域列錶請求
+}
+
+type zoneItem struct {
+	id        string
+	fetchedAt time.Time
+}
+
+func newZoneCache(ttl time.Duration) *zoneCache {
+	return &zoneCache{
+		ttl:    ttl,
+		byName: make(map[string]zoneItem),
+	}
+}
+
+// 取得未過期的區域列錶
+func (z *zoneCache) list() ([]Zone, bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.zones == nil || time.Since(z.fetchedAt) > z.ttl {
+		return nil, false
+	}
+	return z.zones, true
+}
+
+func (z *zoneCache) storeList(zones []Zone) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	now := time.Now()
+	z.zones = zones
+	z.fetchedAt = now
+	for _, zone := range zones {
+		z.byName[strings.ToLower(zone.Name)] = zoneItem{id: zone.ID, fetchedAt: now}
+	}
+}
+
+// 根據區域名稱取得未過期的 Zone ID
+func (z *zoneCache) lookup(name string) (string, bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	item, ok := z.byName[strings.ToLower(name)]
+	if !ok || time.Since(item.fetchedAt) > z.ttl {
+		return "", false
+	}
+	return item.id, true
+}
+
+func (z *zoneCache) store(name, id string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.byName[strings.ToLower(name)] = zoneItem{id: id, fetchedAt: time.Now()}
+}
+
+// 清除快取
+func (z *zoneCache) reset() {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.zones = nil
+	z.byName = make(map[string]zoneItem)
+}
+
+// 清除區域快取，下次操作時重新獲取
+func (c *CloudflareClient) ResetZoneCache() {
+	c.zones.reset()
+}
+
+// 取得記錄所屬的 Zone ID，優先使用配置中的 zone_id / zone
+func (c *CloudflareClient) ResolveZoneID(ctx context.Context, record *config.DNSRecord) (string, error) {
+	if record.ZoneID != "" {
+		return record.ZoneID, nil
+	}
+	if record.Zone != "" {
+		return c.GetZoneIDByName(ctx, record.Zone)
+	}
+	return c.AutoDiscoverZoneID(ctx, record.Name)
+}
+
+// 根據區域名稱查詢 Zone ID（隻查詢單一區域，適用於限定區域的 Token）
+func (c *CloudflareClient) GetZoneIDByName(ctx context.Context, zoneName string) (string, error) {
+	if id, ok := c.zones.lookup(zoneName); ok {
+		return id, nil
+	}
+
+	if verbose {
+		fmt.Printf("🔍 查詢區域: %s\n", zoneName)
+	}
+
+	for zone, err := range paginate[Zone](ctx, c, "/zones", url.Values{"name": {zoneName}}, zonesPerPage) {
+		if err != nil {
+			return "", fmt.Errorf("查詢區域 %s 失敗: %w", zoneName, err)
+		}
+		if strings.EqualFold(zone.Name, zoneName) {
+			c.zones.store(zone.Name, zone.ID)
+			return zone.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("未找到區域: %s", zoneName)
+}
+
+// 自動發現 Zone ID：選擇名稱為記錄後綴的最長區域，
+// 無法獲取區域列錶時（例如 Token 沒有列出區域的權限），依據公共後綴列錶逐級查詢
+func (c *CloudflareClient) AutoDiscoverZoneID(ctx context.Context, dnsRecordName string) (string, error) {
+	recordName := strings.ToLower(strings.TrimSuffix(dnsRecordName, "."))
+	if recordName == "" {
+		return "", fmt.Errorf("無法從記錄名稱中提取域名: %s", dnsRecordName)
+	}
+
+	if verbose {
+		fmt.Printf("🔍 自動發現 Zone ID for: %s\n", recordName)
+	}
+
+	zones, err := c.listZonesCached(ctx)
+	if err == nil {
+		// 區域列錶已包含所有可訪問的區域，沒有匹配時不需要逐級查詢
+		if zone := longestMatchingZone(recordName, zones); zone != nil {
+			if verbose {
+				fmt.Printf("✅ 發現 Zone ID: %s for %s\n", zone.ID, zone.Name)
+			}
+			return zone.ID, nil
+		}
+		return "", fmt.Errorf("未找到記錄 %s 對應的區域，可用區域: %v",
+			recordName, getZoneNames(zones))
+	}
+	if verbose {
+		fmt.Printf("⚠️  獲取區域列錶失敗，改用公共後綴列錶: %v\n", err)
+	}
+
+	// 從記錄名稱逐級向上查詢，直到可註冊域名為止
+	candidates := zoneCandidates(recordName)
+	for _, candidate := range candidates {
+		if id, lookupErr := c.GetZoneIDByName(ctx, candidate); lookupErr == nil {
+			if verbose {
+				fmt.Printf("✅ 發現 Zone ID: %s for %s\n", id, candidate)
+			}
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("獲取區域列錶失敗: %w", err)
+}
+
+// 選擇名稱為記錄後綴的最長區域（支援委派的子區域，例如 lab.corp.example.com）
+func longestMatchingZone(recordName string, zones []Zone) *Zone {
+	var matched *Zone
+	for i := range zones {
+		if !config.IsInZone(recordName, zones[i].Name) {
+			continue
+		}
+		if matched == nil || len(zones[i].Name) > len(matched.Name) {
+			matched = &zones[i]
+		}
+	}
+	return matched
+}
+
+// 可能的區域名稱，由長到短，最短為可註冊域名（依據公共後綴列錶）
+func zoneCandidates(recordName string) []string {
+	registrable := config.ExtractZoneNameFromDNS(recordName)
+	recordName = strings.TrimPrefix(recordName, "*.")
+
+	var candidates []string
+	for name := recordName; ; {
+		candidates = append(candidates, name)
+		if name == registrable {
+			break
+		}
+		dot := strings.Index(name, ".")
+		if dot < 0 || !config.IsInZone(name, registrable) {
+			break
+		}
+		name = name[dot+1:]
+	}
+	return candidates
+}
+
+// 獲取區域列錶，快取未過期時不發送請求
+func (c *CloudflareClient) listZonesCached(ctx context.Context) ([]Zone, error) {
+	if zones, ok := c.zones.list(); ok {
+		return zones, nil
+	}
+
+	c.zones.fetchMu.Lock()
+	defer c.zones.fetchMu.Unlock()
+
+	// 等待期間可能已由其他請求獲取
+	if zones, ok := c.zones.list(); ok {
+		return zones, nil
+	}
+
+	zones, err := c.GetZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.zones.storeList(zones)
+
+	return zones, nil
+}
+
+// 獲取用戶可訪問的所有區域
+func (c *CloudflareClient) GetZones(ctx context.Context) ([]Zone, error) {
+	if verbose {
+		fmt.Printf("🔍 獲取區域列錶...\n")
+	}
+
+	return collect(paginate[Zone](ctx, c, "/zones", nil, zonesPerPage))
+}
+
+func getZoneNames(zones []Zone) []string {
+	var names []string
+	for _, zone := range zones {
+		names = append(names, zone.Name)
+	}
+	return names
+}
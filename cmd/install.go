@@ -205,6 +205,11 @@ global:
     - "https://icanhazip.com"
     - "https://ident.me"
     - "https://4.ipw.cn"
+  ipv6_check_urls:     # 檢查 IPv6 的網站（僅在配置了 AAAA 記錄時使用）
+    - "https://api6.ipify.org"
+    - "https://ipv6.icanhazip.com"
+    - "https://v6.ident.me"
+    - "https://6.ipw.cn"
 
 # Cloudflare 配置
 cloudflare:
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"cfddns/cloudflare"
+	"cfddns/service"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var planOutput string
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "預覽將要執行的 DNS 變更",
+	Long:  "根據當前公共 IP 和配置計算每筆記錄將被創建、更新或保持不變，不修改任何記錄",
+	Run: func(cmd *cobra.Command, args []string) {
+		if planOutput != "table" && planOutput != "json" {
+			fmt.Printf("❌ 不支援的輸出格式: %s (可用: table, json)\n", planOutput)
+			os.Exit(1)
+		}
+
+		cfg, err := getConfig()
+		if err != nil {
+			fmt.Printf("❌ 加載配置失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		service.SetVerbose(verbose)
+		cloudflare.SetVerbose(verbose)
+
+		ddnsService, err := service.NewDDNSService(cfg)
+		if err != nil {
+			fmt.Printf("❌ 初始化服務失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		plan := ddnsService.Plan(cmd.Context())
+
+		if planOutput == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(plan); err != nil {
+				fmt.Printf("❌ 輸出 JSON 失敗: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			printPlanTable(plan)
+		}
+
+		if plan.Count(service.PlanError) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// 以表格顯示計劃
+func printPlanTable(plan *service.Plan) {
+	fmt.Println("📝 DNS 變更計劃")
+	printSeparator(50)
+
+	for _, recordType := range []string{"A", "AAAA"} {
+		if ip, ok := plan.IPs[recordType]; ok {
+			fmt.Printf("📡 當前公共 %s: %s\n", ipLabel(recordType), ip)
+		}
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "操作\t名稱\t類型\t變更")
+	fmt.Fprintln(w, "----\t----\t----\t----")
+	for _, record := range plan.Records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			planActionLabel(record.Action),
+			planRecordName(record),
+			record.Type,
+			record.Diff())
+	}
+	w.Flush()
+
+	fmt.Printf("\n📊 摘要: %d 個創建, %d 個更新, %d 個不變",
+		plan.Count(service.PlanCreate),
+		plan.Count(service.PlanUpdate),
+		plan.Count(service.PlanNoop))
+	if skipped := plan.Count(service.PlanSkip); skipped > 0 {
+		fmt.Printf(", %d 個略過", skipped)
+	}
+	if failed := plan.Count(service.PlanError); failed > 0 {
+		fmt.Printf(", %d 個錯誤", failed)
+	}
+	fmt.Println()
+}
+
+// 記錄的顯示名稱，設置 match 時附加條件
+func planRecordName(record service.RecordPlan) string {
+	if record.Match == "" {
+		return record.Name
+	}
+	return fmt.Sprintf("%s [%s]", record.Name, record.Match)
+}
+
+// 操作的顯示名稱
+func planActionLabel(action service.PlanAction) string {
+	switch action {
+	case service.PlanCreate:
+		return "創建"
+	case service.PlanUpdate:
+		return "更新"
+	case service.PlanSkip:
+		return "略過"
+	case service.PlanError:
+		return "錯誤"
+	default:
+		return "不變"
+	}
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&planOutput, "output", "o", "table", "輸出格式 (table|json)")
+}
@@ -56,6 +56,7 @@ func init() {
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(planCmd)
 	rootCmd.AddCommand(webhookCmd)
 	rootCmd.AddCommand(testCmd)
 	rootCmd.AddCommand(validateCmd)
@@ -2,15 +2,39 @@ package cmd
 
 import (
 	"cfddns/service"
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
+// run --once 的退出碼
+const (
+	exitNoChange = 0 // 所有記錄已同步
+	exitFailed   = 1 // 檢查或更新失敗
+	exitUpdated  = 2 // 有記錄被更新或創建
+)
+
+var (
+	runOnce   bool
+	runDryRun bool
+)
+
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "運行 DDNS 服務",
+	Long: `運行 DDNS 服務，持續監控 IP 變化並更新 DNS 記錄。
+
+使用 --once 只執行一次檢查後退出，適用於 cron、systemd timer 或路由器腳本：
+  退出碼 0: 所有記錄已同步，沒有變更
+  退出碼 1: 檢查或更新失敗
+  退出碼 2: 有記錄被更新或創建
+
+使用 --dry-run 時每次檢查只顯示將要執行的操作（同 plan 命令），不修改任何記錄。`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := getConfig()
 		if err != nil {
@@ -19,13 +43,68 @@ var runCmd = &cobra.Command{
 
 		service.SetVerbose(verbose)
 
-		ddnsService := service.NewDDNSService(cfg)
+		ddnsService, err := service.NewDDNSService(cfg)
+		if err != nil {
+			log.Fatalf("初始化服務失敗: %v", err)
+		}
+		ddnsService.SetDryRun(runDryRun)
+
+		if runOnce {
+			os.Exit(runOnceCheck(cmd.Context(), ddnsService))
+		}
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go handleSignals(signals, ddnsService)
 
 		fmt.Println("🌐 系統啟動")
 		printSeparator(50)
 
-		if err := ddnsService.Start(); err != nil {
+		if err := ddnsService.Start(cmd.Context()); err != nil {
 			log.Fatalf("服務運行失敗: %v", err)
 		}
 	},
 }
+
+// 執行單次檢查，回傳退出碼
+func runOnceCheck(ctx context.Context, ddnsService *service.DDNSService) int {
+	result, err := ddnsService.RunOnce(ctx)
+	if err != nil {
+		fmt.Printf("❌ 檢查失敗: %v\n", err)
+		return exitFailed
+	}
+	if result.Failed > 0 {
+		return exitFailed
+	}
+	if result.Updated > 0 {
+		return exitUpdated
+	}
+	return exitNoChange
+}
+
+// 處理系統信號：SIGHUP 重新加載配置；SIGTERM / SIGINT 完成當前檢查後停止，
+// 再次收到時立即停止
+func handleSignals(signals <-chan os.Signal, ddnsService *service.DDNSService) {
+	stopping := false
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			ddnsService.Reload()
+			continue
+		}
+
+		if stopping {
+			fmt.Printf("\n⚠️  再次收到 %s，立即停止\n", sig)
+			ddnsService.Stop()
+			continue
+		}
+		stopping = true
+		fmt.Printf("\n🛑 收到 %s，完成當前檢查後停止（再次發送將立即停止）\n", sig)
+		ddnsService.Shutdown()
+	}
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&runDryRun, "dry-run", false, "只顯示將要執行的操作，不修改記錄")
+	runCmd.Flags().BoolVar(&runOnce, "once", false, "只執行一次檢查後退出 (退出碼: 0=無變更, 1=失敗, 2=已更新)")
+}
@@ -26,19 +26,32 @@ var statusCmd = &cobra.Command{
 		cloudflare.SetVerbose(verbose)
 
 		// 創建服務實例
-		ddnsService := service.NewDDNSService(cfg)
+		ddnsService, err := service.NewDDNSService(cfg)
+		if err != nil {
+			fmt.Printf("❌ 初始化服務失敗: %v\n", err)
+			return
+		}
 
 		fmt.Println("🌐 DNS 記錄狀態檢查")
 		printSeparator(50)
 
-		// 獲取當前公共 IP
-		currentIP, err := ddnsService.GetCurrentIP()
-		if err != nil {
-			fmt.Printf("❌ 獲取當前 IP 失敗: %v\n", err)
-			currentIP = "未知"
-		} else {
-			fmt.Printf("📡 當前公共 IP: %s\n\n", currentIP)
+		// 獲取各記錄類型對應的當前公共 IP
+		currentIPs := make(map[string]string)
+		for _, recordType := range []string{"A", "AAAA"} {
+			if !cfg.HasRecordType(recordType) {
+				continue
+			}
+			label := ipLabel(recordType)
+			currentIP, err := ddnsService.GetCurrentIPForType(cmd.Context(), recordType)
+			if err != nil {
+				fmt.Printf("❌ 獲取當前 %s 失敗: %v\n", label, err)
+				currentIP = "未知"
+			} else {
+				fmt.Printf("📡 當前公共 %s: %s\n", label, currentIP)
+			}
+			currentIPs[recordType] = currentIP
 		}
+		fmt.Println()
 
 		// 顯示設定的 DNS 記錄狀態
 		fmt.Println("📋 設定的 DNS 記錄狀態:")
@@ -53,13 +66,23 @@ var statusCmd = &cobra.Command{
 		fmt.Fprintln(w, "名稱\t類型\t代理\tTTL\tDNS IP\t狀態\t同步")
 		fmt.Fprintln(w, "----\t----\t----\t---\t-------\t----\t----")
 
-		cfClient := cloudflare.NewClient(&cfg.Cloudflare)
+		cfClient, err := cloudflare.NewClient(&cfg.Cloudflare)
+		if err != nil {
+			fmt.Printf("❌ 創建 Cloudflare 客戶端失敗: %v\n", err)
+			return
+		}
 		successCount := 0
 		totalCount := len(cfg.DNSRecords)
+		unknownIP := false
 
 		for _, record := range cfg.DNSRecords {
+			currentIP := currentIPs[record.Type]
+			if currentIP == "未知" {
+				unknownIP = true
+			}
+
 			// 獲取 Cloudflare 中的實際記錄
-			cfRecord, err := cfClient.GetDNSRecord(record.Name, record.Type)
+			cfRecord, err := cfClient.GetDNSRecord(cmd.Context(), &record)
 
 			var dnsIP string
 			var status string
@@ -74,7 +97,7 @@ var statusCmd = &cobra.Command{
 				status = "存在"
 
 				// 檢查同步狀態
-				if currentIP != "未知" && cfRecord.Content == currentIP {
+				if currentIP != "未知" && service.SameIP(cfRecord.Content, currentIP) {
 					syncStatus = "✅"
 					successCount++
 				} else if currentIP != "未知" {
@@ -109,9 +132,9 @@ var statusCmd = &cobra.Command{
 
 		// 顯示摘要信息
 		fmt.Printf("\n📊 摘要: ")
-		if successCount == totalCount && currentIP != "未知" {
+		if successCount == totalCount && !unknownIP {
 			fmt.Printf("✅ 所有記錄已同步 (%d/%d)\n", successCount, totalCount)
-		} else if currentIP != "未知" {
+		} else if !unknownIP {
 			fmt.Printf("⚠️  %d/%d 個記錄已同步\n", successCount, totalCount)
 		} else {
 			fmt.Printf("❓ 無法檢查同步狀態 (IP 獲取失敗)\n")
@@ -121,6 +144,14 @@ var statusCmd = &cobra.Command{
 	},
 }
 
+// 記錄類型對應的 IP 顯示名稱
+func ipLabel(recordType string) string {
+	if recordType == "AAAA" {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
 // 格式化 TTL 顯示
 func formatTTL(ttl int) string {
 	if ttl == 1 {
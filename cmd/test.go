@@ -22,12 +22,16 @@ var testCmd = &cobra.Command{
 		printSeparator(50)
 
 		// 測試 API 連接
-		cfClient := cloudflare.NewClient(&cfg.Cloudflare)
+		cfClient, err := cloudflare.NewClient(&cfg.Cloudflare)
+		if err != nil {
+			fmt.Printf("❌ 創建 Cloudflare 客戶端失敗: %v\n", err)
+			return
+		}
 		cloudflare.SetVerbose(verbose)
 
 		// 1. 測試 API Token
 		fmt.Println("\n1. 🔗 測試 API Token...")
-		if err := cfClient.TestConnection(); err != nil {
+		if err := cfClient.TestConnection(cmd.Context()); err != nil {
 			fmt.Printf("❌ API Token 測試失敗: %v\n", err)
 			return
 		}
@@ -39,7 +43,7 @@ var testCmd = &cobra.Command{
 		} else {
 			for i, record := range cfg.DNSRecords {
 				fmt.Printf("   記錄 %d: %s (%s)... ", i+1, record.Name, record.Type)
-				cfRecord, err := cfClient.GetDNSRecord(record.Name, record.Type)
+				cfRecord, err := cfClient.GetDNSRecord(cmd.Context(), &record)
 				if err != nil {
 					fmt.Printf("❌ 訪問失敗: %v\n", err)
 				} else {
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"cfddns/config"
 	"fmt"
+	"net/url"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -41,9 +43,10 @@ var validateCmd = &cobra.Command{
 		if _, err := os.Stat(".env"); err == nil {
 			// 檢查環境變量
 			fmt.Println("🌍 環境變量檢查:")
-			checkEnvVar("CF_API_TOKEN")
-			checkEnvVar("WEBHOOK_URL")
-			checkEnvVar("WEBHOOK_CHAT_ID")
+			checkEnvVar(cfg, "CF_API_TOKEN")
+			checkEnvVar(cfg, "CF_API_BASE_URL")
+			checkEnvVar(cfg, "WEBHOOK_URL")
+			checkEnvVar(cfg, "WEBHOOK_CHAT_ID")
 			fmt.Println()
 		}
 
@@ -51,6 +54,7 @@ var validateCmd = &cobra.Command{
 		fmt.Println("📋 配置來源:")
 		sources := cfg.GetConfigSource()
 		fmt.Printf("   Cloudflare API Token: %s\n", sources["cloudflare.api_token"])
+		fmt.Printf("   Cloudflare API 端點: %s\n", sources["cloudflare.api_base_url"])
 		fmt.Printf("   Webhook URL: %s\n", sources["webhook.url"])
 		fmt.Printf("   Webhook Chat ID: %s\n", sources["webhook.chat_id"])
 		fmt.Println()
@@ -58,13 +62,26 @@ var validateCmd = &cobra.Command{
 		// 顯示配置摘要（隱藏敏感信息）
 		fmt.Println("📋 配置摘要:")
 		fmt.Printf("   Cloudflare API Token: %s\n", maskString(cfg.Cloudflare.APIToken, 8))
+		if cfg.Cloudflare.BaseURL != "" {
+			fmt.Printf("   Cloudflare API 端點: %s\n", cfg.Cloudflare.BaseURL)
+		}
+		if cfg.Cloudflare.Proxy != "" {
+			fmt.Printf("   Cloudflare 代理: %s\n", redactURL(cfg.Cloudflare.Proxy))
+		}
 		fmt.Printf("   DNS 記錄數量: %d\n", len(cfg.DNSRecords))
+		if len(cfg.RecordSets) > 0 {
+			fmt.Printf("   記錄模板數量: %d\n", len(cfg.RecordSets))
+		}
 		for i, record := range cfg.DNSRecords {
 			ttlDesc := "自動"
 			if record.TTL != 1 {
 				ttlDesc = formatTTL(record.TTL)
 			}
-			fmt.Printf("     %d. %s (%s) - TTL: %s\n", i+1, record.Name, record.Type, ttlDesc)
+			source := ""
+			if record.FromRecordSet {
+				source = " [模板]"
+			}
+			fmt.Printf("     %d. %s (%s) - TTL: %s%s\n", i+1, record.Name, record.Type, ttlDesc, source)
 		}
 		fmt.Printf("   Webhook 啟用: %v\n", cfg.Webhook.Enabled)
 		if cfg.Webhook.Enabled {
@@ -79,8 +96,17 @@ var validateCmd = &cobra.Command{
 	},
 }
 
-func checkEnvVar(name string) {
-	value := os.Getenv(name)
+// 隱藏 URL 中的密碼
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return maskString(raw, 8)
+	}
+	return u.Redacted()
+}
+
+func checkEnvVar(cfg *config.Config, name string) {
+	value := cfg.Getenv(name)
 	if value == "" {
 		fmt.Printf("   ❌ %s: 未設置\n", name)
 	} else {
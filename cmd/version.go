@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"cfddns/cloudflare"
 	"fmt"
 	"runtime"
 
@@ -15,6 +16,7 @@ var (
 func SetVersionInfo(v, t string) {
 	version = v
 	buildTime = t
+	cloudflare.SetDefaultUserAgent("cfddns/" + v)
 }
 
 var versionCmd = &cobra.Command{
@@ -55,10 +55,16 @@ var webhookCmd = &cobra.Command{
 		)
 
 		// 其餘代碼保持不變...
-		ddnsService := service.NewDDNSService(cfg)
-		currentIP, ipErr := ddnsService.GetCurrentIP()
-		if ipErr != nil && verbose {
-			fmt.Printf("⚠️  獲取當前 IP 失敗: %v\n", ipErr)
+		var currentIP string
+		ddnsService, err := service.NewDDNSService(cfg)
+		if err != nil {
+			fmt.Printf("⚠️  初始化服務失敗: %v\n", err)
+		} else {
+			var ipErr error
+			currentIP, ipErr = ddnsService.GetCurrentIP(cmd.Context())
+			if ipErr != nil && verbose {
+				fmt.Printf("⚠️  獲取當前 IP 失敗: %v\n", ipErr)
+			}
 		}
 
 		fmt.Printf("🔔 發送 Webhook 測試訊息到: %s\n", cfg.Webhook.URL)
@@ -71,16 +77,16 @@ var webhookCmd = &cobra.Command{
 
 		switch webhookType {
 		case "success":
-			sendErr = webhookClient.SendSuccess(currentIP, currentIP, "test.example.com")
+			sendErr = webhookClient.SendSuccess(cmd.Context(), currentIP, currentIP, "test.example.com")
 			fmt.Println("📤 發送成功通知...")
 		case "error":
-			sendErr = webhookClient.SendFailure("test.example.com", "這是一個測試錯誤訊息")
+			sendErr = webhookClient.SendFailure(cmd.Context(), "test.example.com", "這是一個測試錯誤訊息")
 			fmt.Println("📤 發送錯誤通知...")
 		default: // 包括 "info" 和空字符串
 			if webhookMessage == "" {
 				message = "DDNS 服務測試通知"
 			}
-			sendErr = webhookClient.SendInfo(message)
+			sendErr = webhookClient.SendInfo(cmd.Context(), message)
 			fmt.Println("📤 發送信息通知...")
 		}
 
@@ -1,30 +1,216 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"golang.org/x/net/publicsuffix"
 	"gopkg.in/yaml.v3"
 )
 
 type GlobalConfig struct {
-	CheckInterval int      `yaml:"check_interval"`
-	IPCheckURLs   []string `yaml:"ip_check_urls"`
+	CheckInterval  int      `yaml:"check_interval"`
+	CycleTimeout   int      `yaml:"cycle_timeout"`   // 單次檢查的最長時間(秒)
+	VerifyInterval int      `yaml:"verify_interval"` // 忽略暫存、實際檢查 Cloudflare 記錄的間隔(秒)
+	Concurrency    int      `yaml:"concurrency"`     // 同時處理的記錄數
+	IPCheckURLs    []string `yaml:"ip_check_urls"`   // IPv4 檢查服務
+	IPv6CheckURLs  []string `yaml:"ipv6_check_urls"` // IPv6 檢查服務
+
+	IPSource  string          `yaml:"ip_source"` // 公共 IP 來源：http（預設）或 interface
+	Interface InterfaceConfig `yaml:"interface"` // ip_source 為 interface 時使用的網絡介面
+	Consensus ConsensusConfig `yaml:"consensus"` // 多個 IP 檢查服務的共識
 }
 
+// IP 檢查服務的共識設定：同時查詢多個服務，足夠多的服務回傳相同地址才採用
+type ConsensusConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	Providers int  `yaml:"providers"` // 同時查詢的服務數（依列錶順序），預設 3
+	MinAgree  int  `yaml:"min_agree"` // 至少幾個服務回傳相同地址，預設 2
+}
+
+// ip_check_urls 中以 DNS 查詢公共 IP 的項目前綴，例如 "dns:cloudflare"
+const DNSCheckPrefix = "dns:"
+
+// 支援的 DNS 查詢服務
+var DNSCheckProviders = []string{"opendns", "cloudflare", "google"}
+
+// ip_check_urls 中以 STUN 查詢公共 IP 的項目前綴，例如 "stun:stun.l.google.com:19302"
+const STUNCheckPrefix = "stun:"
+
+// ip_check_urls 中向路由器查詢 WAN 地址的項目："router" 依序嘗試所有協議，或 "router:<協議>"
+const RouterCheckPrefix = "router"
+
+// 支援的路由器協議，依嘗試順序排列
+var RouterProtocols = []string{"upnp", "natpmp", "pcp"}
+
+// 解析路由器查詢項目，回傳依序嘗試的協議；不是路由器項目時 ok 為 false
+func RouterCheckProtocols(entry string) (protocols []string, ok bool) {
+	if entry == RouterCheckPrefix {
+		return RouterProtocols, true
+	}
+	protocol, ok := strings.CutPrefix(entry, RouterCheckPrefix+":")
+	if !ok {
+		return nil, false
+	}
+	return []string{protocol}, true
+}
+
+// STUN 伺服器的預設埠
+const DefaultSTUNPort = "3478"
+
+// STUN 伺服器地址，未指定埠時使用預設埠
+func STUNServerAddr(server string) (string, error) {
+	if server == "" {
+		return "", errors.New("未指定 STUN 伺服器")
+	}
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		// 未指定埠（IPv6 地址需要以方括號包住）
+		host, port = strings.Trim(server, "[]"), DefaultSTUNPort
+	}
+	if host == "" {
+		return "", fmt.Errorf("STUN 伺服器無效: %s", server)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", fmt.Errorf("STUN 伺服器的埠無效: %s", server)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// 公共 IP 來源
+const (
+	IPSourceHTTP      = "http"      // 查詢 IP 檢查服務
+	IPSourceInterface = "interface" // 讀取本機網絡介面的地址
+)
+
+// 從網絡介面讀取地址的設定
+type InterfaceConfig struct {
+	Name       string `yaml:"name"`       // 網絡介面名稱，例如 pppoe-wan
+	Scope      string `yaml:"scope"`      // 地址範圍：global（預設，只使用公共地址）或 any
+	CIDR       string `yaml:"cidr"`       // 只使用此網段內的地址，只限制相同協議族（可選）
+	Temporary  bool   `yaml:"temporary"`  // 允許 IPv6 臨時地址（隱私擴展）
+	Deprecated bool   `yaml:"deprecated"` // 允許已棄用的 IPv6 地址
+}
+
+// 介面地址範圍
+const (
+	ScopeGlobal = "global" // 只使用公共地址
+	ScopeAny    = "any"    // 不限制
+)
+
 type CloudflareConfig struct {
-	APIToken string `yaml:"api_token"`
+	APIToken  string `yaml:"api_token"`
+	BaseURL   string `yaml:"api_base_url"` // API 端點，留空使用官方端點
+	UserAgent string `yaml:"user_agent"`
+	Proxy     string `yaml:"proxy"`   // HTTP(S) 代理，留空則使用 HTTPS_PROXY 環境變量
+	CAFile    string `yaml:"ca_file"` // 額外信任的 CA 憑證 (PEM)
+	Timeout   int    `yaml:"timeout"` // 請求超時(秒)
+
+	ZoneCacheTTL int             `yaml:"zone_cache_ttl"` // 區域快取時間(秒)
+	Retry        RetryConfig     `yaml:"retry"`
+	RateLimit    RateLimitConfig `yaml:"rate_limit"`
+	Ownership    OwnershipConfig `yaml:"ownership"`
+}
+
+// API 請求重試設定
+type RetryConfig struct {
+	MaxAttempts int `yaml:"max_attempts"` // 最大嘗試次數（含首次），1 = 不重試
+	BaseDelay   int `yaml:"base_delay"`   // 初始退避時間(秒)，之後每次加倍
+	MaxDelay    int `yaml:"max_delay"`    // 最大退避時間(秒)
+}
+
+// 記錄擁有者標記設定：創建或更新記錄時寫入標記，不修改其他實例管理的記錄
+type OwnershipConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	OwnerID string `yaml:"owner_id"` // 實例識別碼，預設為主機名稱
+	Mode    string `yaml:"mode"`     // 標記方式：comment（預設）或 tag
+}
+
+// 擁有者標記方式
+const (
+	OwnershipComment = "comment" // 寫入記錄備註
+	OwnershipTag     = "tag"     // 寫入記錄標籤（需要付費方案）
+
+	OwnerCommentPrefix = "managed-by=cfddns host=" // 備註中的擁有者標記，後接實例識別碼
+	OwnerTagPrefix     = "cfddns-owner:"           // 擁有者標籤，後接實例識別碼
+)
+
+// 移除備註中的擁有者標記，回傳使用者自己的備註
+func StripOwnerComment(comment string) string {
+	if before, rest, ok := strings.Cut(comment, OwnerCommentPrefix); ok {
+		_, after, _ := strings.Cut(rest, " ")
+		comment = strings.TrimSpace(before) + " " + strings.TrimSpace(after)
+	}
+	return strings.TrimSpace(comment)
+}
+
+// API 請求配額設定（Cloudflare 預設每個 Token 5 分鐘 1200 次）
+type RateLimitConfig struct {
+	Requests int `yaml:"requests"` // 時間窗口內最多請求數
+	Window   int `yaml:"window"`   // 時間窗口(秒)
 }
 
 type DNSRecord struct {
-	Name    string `yaml:"name"`
-	Type    string `yaml:"type"`
-	Proxied bool   `yaml:"proxied"`
-	TTL     int    `yaml:"ttl"`
+	Name            string      `yaml:"name"`
+	Type            string      `yaml:"type"`
+	Proxied         bool        `yaml:"proxied"`
+	TTL             int         `yaml:"ttl"`
+	CreateIfMissing bool        `yaml:"create_if_missing"` // 記錄不存在時自動創建
+	Zone            string      `yaml:"zone"`              // 所屬區域名稱（可選）
+	ZoneID          string      `yaml:"zone_id"`           // 所屬區域 ID（可選，設置後不需要列出區域）
+	Drift           string      `yaml:"drift"`             // proxied / ttl 與配置不同時的處理方式
+	Match           RecordMatch `yaml:"match"`             // 同名稱多筆記錄時，以備註或標籤選擇管理的記錄
+	Takeover        bool        `yaml:"takeover"`          // 允許接管其他實例標記的記錄
+	FromRecordSet   bool        `yaml:"-"`                 // 由 record_sets 展開
 }
 
+// 選擇記錄的條件，兩者都設置時必須同時符合
+type RecordMatch struct {
+	Comment string `yaml:"comment"` // 備註（不含擁有者標記）與此文字完全相同
+	Tag     string `yaml:"tag"`     // 帶有此標籤，例如 "site:home"
+}
+
+// 是否未設置任何條件
+func (m RecordMatch) IsZero() bool {
+	return m.Comment == "" && m.Tag == ""
+}
+
+// 條件的描述，例如 "comment=site-a, tag=site:a"
+func (m RecordMatch) String() string {
+	var parts []string
+	if m.Comment != "" {
+		parts = append(parts, "comment="+m.Comment)
+	}
+	if m.Tag != "" {
+		parts = append(parts, "tag="+m.Tag)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 記錄的識別鍵：名稱/類型，設置 match 時附加條件，例如 "www.example.com/A[tag=site:a]"
+func (r *DNSRecord) Key() string {
+	key := r.Name + "/" + r.Type
+	if !r.Match.IsZero() {
+		key += "[" + r.Match.String() + "]"
+	}
+	return key
+}
+
+// proxied / ttl 偏移的處理方式
+const (
+	DriftEnforce = "enforce" // 恢復為配置的值（預設）
+	DriftWarn    = "warn"    // 只顯示警告
+	DriftIgnore  = "ignore"  // 不檢查
+)
+
 type WebhookConfig struct {
 	Enabled   bool   `yaml:"enabled"`
 	Type      string `yaml:"type"`
@@ -36,12 +222,14 @@ type WebhookConfig struct {
 }
 
 type Config struct {
-	Global       GlobalConfig     `yaml:"global"`
-	Cloudflare   CloudflareConfig `yaml:"cloudflare"`
-	DNSRecords   []DNSRecord      `yaml:"dns_records"`
-	Webhook      WebhookConfig    `yaml:"webhook"`
-	ConfigPath   string           `yaml:"-"`
-	LastModified time.Time        `yaml:"-"`
+	Global       GlobalConfig      `yaml:"global"`
+	Cloudflare   CloudflareConfig  `yaml:"cloudflare"`
+	DNSRecords   []DNSRecord       `yaml:"dns_records"`
+	RecordSets   []RecordSet       `yaml:"record_sets"` // 記錄模板，加載時展開到 DNSRecords
+	Webhook      WebhookConfig     `yaml:"webhook"`
+	ConfigPath   string            `yaml:"-"`
+	LastModified time.Time         `yaml:"-"`
+	DotEnv       map[string]string `yaml:"-"` // .env 檔案中的變量
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -63,16 +251,93 @@ func LoadConfig(path string) (*Config, error) {
 	config.ConfigPath = path
 	config.LastModified = info.ModTime()
 
+	// 展開記錄模板，之後的預設值和驗證同樣套用於展開的記錄
+	config.expandRecordSets()
+
 	// 設置默認值
 	if config.Global.CheckInterval == 0 {
 		config.Global.CheckInterval = 300
 	}
+	if config.Global.CycleTimeout == 0 {
+		config.Global.CycleTimeout = 120
+	}
+	if config.Global.VerifyInterval == 0 {
+		config.Global.VerifyInterval = 3600
+	}
+	if config.Global.Concurrency == 0 {
+		config.Global.Concurrency = 4
+	}
 	if len(config.Global.IPCheckURLs) == 0 {
 		config.Global.IPCheckURLs = []string{
 			"https://api.ipify.org",
 			"https://icanhazip.com",
 			"https://ident.me",
 			"https://4.ipw.cn",
+			"dns:cloudflare", // HTTP 服務都失敗時改用 DNS 查詢
+		}
+	}
+	if len(config.Global.IPv6CheckURLs) == 0 {
+		config.Global.IPv6CheckURLs = []string{
+			"https://api6.ipify.org",
+			"https://ipv6.icanhazip.com",
+			"https://v6.ident.me",
+			"https://6.ipw.cn",
+			"dns:cloudflare", // HTTP 服務都失敗時改用 DNS 查詢
+		}
+	}
+	config.Global.IPSource = strings.ToLower(strings.TrimSpace(config.Global.IPSource))
+	if config.Global.IPSource == "" {
+		config.Global.IPSource = IPSourceHTTP
+	}
+	if config.Global.Consensus.Providers == 0 {
+		config.Global.Consensus.Providers = 3
+	}
+	if config.Global.Consensus.MinAgree == 0 {
+		config.Global.Consensus.MinAgree = 2
+	}
+	config.Global.Interface.Scope = strings.ToLower(strings.TrimSpace(config.Global.Interface.Scope))
+	if config.Global.Interface.Scope == "" {
+		config.Global.Interface.Scope = ScopeGlobal
+	}
+	for i := range config.DNSRecords {
+		// 記錄類型統一為大寫，未設置時預設為 A
+		config.DNSRecords[i].Type = strings.ToUpper(strings.TrimSpace(config.DNSRecords[i].Type))
+		if config.DNSRecords[i].Type == "" {
+			config.DNSRecords[i].Type = "A"
+		}
+		config.DNSRecords[i].Drift = strings.ToLower(strings.TrimSpace(config.DNSRecords[i].Drift))
+		if config.DNSRecords[i].Drift == "" {
+			config.DNSRecords[i].Drift = DriftEnforce
+		}
+	}
+	if config.Cloudflare.Timeout == 0 {
+		config.Cloudflare.Timeout = 30
+	}
+	if config.Cloudflare.ZoneCacheTTL == 0 {
+		config.Cloudflare.ZoneCacheTTL = 3600
+	}
+	if config.Cloudflare.Retry.MaxAttempts == 0 {
+		config.Cloudflare.Retry.MaxAttempts = 4
+	}
+	if config.Cloudflare.Retry.BaseDelay == 0 {
+		config.Cloudflare.Retry.BaseDelay = 1
+	}
+	if config.Cloudflare.Retry.MaxDelay == 0 {
+		config.Cloudflare.Retry.MaxDelay = 30
+	}
+	if config.Cloudflare.RateLimit.Requests == 0 {
+		config.Cloudflare.RateLimit.Requests = 1200
+	}
+	if config.Cloudflare.RateLimit.Window == 0 {
+		config.Cloudflare.RateLimit.Window = 300
+	}
+	config.Cloudflare.Ownership.Mode = strings.ToLower(strings.TrimSpace(config.Cloudflare.Ownership.Mode))
+	if config.Cloudflare.Ownership.Mode == "" {
+		config.Cloudflare.Ownership.Mode = OwnershipComment
+	}
+	if config.Cloudflare.Ownership.OwnerID == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			config.Cloudflare.Ownership.OwnerID = hostname
 		}
 	}
 	if config.Webhook.Template == "" {
@@ -91,17 +356,22 @@ func (c *Config) loadFromEnv() {
 	c.loadDotEnv()
 
 	// Cloudflare API Token: .env 優先，如果未設置則使用 config.yaml
-	if envToken := os.Getenv("CF_API_TOKEN"); envToken != "" {
+	if envToken := c.Getenv("CF_API_TOKEN"); envToken != "" {
 		c.Cloudflare.APIToken = envToken
 	}
 
+	// Cloudflare API 端點: .env 優先，如果未設置則使用 config.yaml
+	if envBaseURL := c.Getenv("CF_API_BASE_URL"); envBaseURL != "" {
+		c.Cloudflare.BaseURL = envBaseURL
+	}
+
 	// Webhook URL: .env 優先，如果未設置則使用 config.yaml
-	if envURL := os.Getenv("WEBHOOK_URL"); envURL != "" {
+	if envURL := c.Getenv("WEBHOOK_URL"); envURL != "" {
 		c.Webhook.URL = envURL
 	}
 
 	// Webhook Chat ID: .env 優先，如果未設置則使用 config.yaml
-	if envChatID := os.Getenv("WEBHOOK_CHAT_ID"); envChatID != "" {
+	if envChatID := c.Getenv("WEBHOOK_CHAT_ID"); envChatID != "" {
 		c.Webhook.ChatID = envChatID
 	}
 
@@ -109,15 +379,26 @@ func (c *Config) loadFromEnv() {
 	if c.Webhook.URL != "" && !c.Webhook.Enabled {
 		// 隻有在 config.yaml 中沒有明確設置 enabled 時才自動啟用
 		// 這裡我們檢查 URL 是否來自 .env，如果是則自動啟用
-		if os.Getenv("WEBHOOK_URL") != "" {
+		if c.Getenv("WEBHOOK_URL") != "" {
 			c.Webhook.Enabled = true
 		}
 	}
 }
 
-// 加載 .env 檔案
+// .env 檔案路徑（相對於工作目錄）
+const DotEnvPath = ".env"
+
+// 讀取變量：.env 中的值優先，未設置時使用系統環境變量
+func (c *Config) Getenv(key string) string {
+	if value := c.DotEnv[key]; value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// 加載 .env 檔案到 DotEnv，不修改程序的環境變量（重新加載時刪除的變量隨之失效）
 func (c *Config) loadDotEnv() {
-	envPath := ".env"
+	envPath := DotEnvPath
 	if _, err := os.Stat(envPath); err != nil {
 		// .env 檔案不存在，跳過
 		return
@@ -129,6 +410,7 @@ func (c *Config) loadDotEnv() {
 		return
 	}
 
+	c.DotEnv = make(map[string]string)
 	lines := strings.SplitSeq(string(data), "\n")
 	for line := range lines {
 		line = strings.TrimSpace(line)
@@ -147,27 +429,47 @@ func (c *Config) loadDotEnv() {
 				value = value[1 : len(value)-1]
 			}
 
-			os.Setenv(key, value)
+			c.DotEnv[key] = value
 		}
 	}
 }
 
+// 變量的來源：.env、環境變量，都未設置時回傳空字串
+func (c *Config) envSource(key string) string {
+	switch {
+	case c.DotEnv[key] != "":
+		return ".env"
+	case os.Getenv(key) != "":
+		return "環境變量"
+	}
+	return ""
+}
+
 // 獲取配置來源信息（用於調試）
 func (c *Config) GetConfigSource() map[string]string {
 	source := make(map[string]string)
 
 	// Cloudflare API Token 來源
-	if os.Getenv("CF_API_TOKEN") != "" {
-		source["cloudflare.api_token"] = ".env"
+	if envSource := c.envSource("CF_API_TOKEN"); envSource != "" {
+		source["cloudflare.api_token"] = envSource
 	} else if c.Cloudflare.APIToken != "" {
 		source["cloudflare.api_token"] = "config.yaml"
 	} else {
 		source["cloudflare.api_token"] = "未設置"
 	}
 
+	// Cloudflare API 端點來源
+	if envSource := c.envSource("CF_API_BASE_URL"); envSource != "" {
+		source["cloudflare.api_base_url"] = envSource
+	} else if c.Cloudflare.BaseURL != "" {
+		source["cloudflare.api_base_url"] = "config.yaml"
+	} else {
+		source["cloudflare.api_base_url"] = "預設"
+	}
+
 	// Webhook URL 來源
-	if os.Getenv("WEBHOOK_URL") != "" {
-		source["webhook.url"] = ".env"
+	if envSource := c.envSource("WEBHOOK_URL"); envSource != "" {
+		source["webhook.url"] = envSource
 	} else if c.Webhook.URL != "" {
 		source["webhook.url"] = "config.yaml"
 	} else {
@@ -175,8 +477,8 @@ func (c *Config) GetConfigSource() map[string]string {
 	}
 
 	// Webhook Chat ID 來源
-	if os.Getenv("WEBHOOK_CHAT_ID") != "" {
-		source["webhook.chat_id"] = ".env"
+	if envSource := c.envSource("WEBHOOK_CHAT_ID"); envSource != "" {
+		source["webhook.chat_id"] = envSource
 	} else if c.Webhook.ChatID != "" {
 		source["webhook.chat_id"] = "config.yaml"
 	} else {
@@ -186,6 +488,35 @@ func (c *Config) GetConfigSource() map[string]string {
 	return source
 }
 
+// 檢查 IP 檢查服務的格式：HTTP(S) 網址、"dns:<服務>"、"stun:<伺服器>" 或 "router[:<協議>]"
+func validateCheckURL(entry string) error {
+	if protocols, ok := RouterCheckProtocols(entry); ok {
+		for _, protocol := range protocols {
+			if !slices.Contains(RouterProtocols, protocol) {
+				return fmt.Errorf("%s (路由器協議僅支援 %s)", entry, strings.Join(RouterProtocols, "、"))
+			}
+		}
+		return nil
+	}
+	if server, ok := strings.CutPrefix(entry, STUNCheckPrefix); ok {
+		if _, err := STUNServerAddr(server); err != nil {
+			return fmt.Errorf("%s (%w)", entry, err)
+		}
+		return nil
+	}
+	if name, ok := strings.CutPrefix(entry, DNSCheckPrefix); ok {
+		if !slices.Contains(DNSCheckProviders, name) {
+			return fmt.Errorf("%s (DNS 服務僅支援 %s)", entry, strings.Join(DNSCheckProviders, "、"))
+		}
+		return nil
+	}
+
+	if u, err := url.Parse(entry); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New(entry)
+	}
+	return nil
+}
+
 // 驗證配置是否完整
 func (c *Config) Validate() error {
 	var msg strings.Builder
@@ -194,17 +525,123 @@ func (c *Config) Validate() error {
 		msg.WriteString("   Cloudflare API Token 未設置\n")
 	}
 
+	// 檢查 Cloudflare 連線設置
+	if c.Cloudflare.BaseURL != "" {
+		if u, err := url.Parse(c.Cloudflare.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			msg.WriteString(fmt.Sprintf("   Cloudflare API 端點無效: %s\n", c.Cloudflare.BaseURL))
+		}
+	}
+	if c.Cloudflare.Proxy != "" {
+		if u, err := url.Parse(c.Cloudflare.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			msg.WriteString(fmt.Sprintf("   Cloudflare 代理地址無效: %s\n", c.Cloudflare.Proxy))
+		}
+	}
+	if c.Cloudflare.CAFile != "" {
+		if _, err := os.Stat(c.Cloudflare.CAFile); err != nil {
+			msg.WriteString(fmt.Sprintf("   CA 憑證檔案不存在: %s\n", c.Cloudflare.CAFile))
+		}
+	}
+	switch c.Global.IPSource {
+	case IPSourceHTTP:
+	case IPSourceInterface:
+		if c.Global.Interface.Name == "" {
+			msg.WriteString("   ip_source 為 interface 時必須設置 interface.name\n")
+		}
+		switch c.Global.Interface.Scope {
+		case ScopeGlobal, ScopeAny:
+		default:
+			msg.WriteString(fmt.Sprintf("   介面地址範圍無效: %s (僅支援 global 或 any)\n", c.Global.Interface.Scope))
+		}
+		if c.Global.Interface.CIDR != "" {
+			if _, err := netip.ParsePrefix(c.Global.Interface.CIDR); err != nil {
+				msg.WriteString(fmt.Sprintf("   介面網段無效: %s\n", c.Global.Interface.CIDR))
+			}
+		}
+	default:
+		msg.WriteString(fmt.Sprintf("   IP 來源無效: %s (僅支援 http 或 interface)\n", c.Global.IPSource))
+	}
+	for _, entry := range slices.Concat(c.Global.IPCheckURLs, c.Global.IPv6CheckURLs) {
+		if err := validateCheckURL(entry); err != nil {
+			msg.WriteString(fmt.Sprintf("   IP 檢查服務無效: %v\n", err))
+		}
+	}
+	if consensus := c.Global.Consensus; consensus.Enabled {
+		if consensus.MinAgree < 1 || consensus.Providers < consensus.MinAgree {
+			msg.WriteString(fmt.Sprintf("   共識設定無效: 查詢 %d 個服務、至少 %d 個相同 (min_agree 必須介於 1 和 providers 之間)\n",
+				consensus.Providers, consensus.MinAgree))
+		}
+		for _, family := range []struct {
+			recordType string
+			urls       []string
+		}{{"A", c.Global.IPCheckURLs}, {"AAAA", c.Global.IPv6CheckURLs}} {
+			if c.HasRecordType(family.recordType) && len(family.urls) < consensus.MinAgree {
+				msg.WriteString(fmt.Sprintf("   共識需要至少 %d 個 %s 記錄的 IP 檢查服務，只配置了 %d 個\n",
+					consensus.MinAgree, family.recordType, len(family.urls)))
+			}
+		}
+	}
+	if c.Global.Concurrency < 0 {
+		msg.WriteString(fmt.Sprintf("   並發數無效: %d\n", c.Global.Concurrency))
+	}
+	if c.Global.VerifyInterval < 0 {
+		msg.WriteString(fmt.Sprintf("   實際檢查間隔無效: %d\n", c.Global.VerifyInterval))
+	}
+	if c.Global.CycleTimeout < 0 {
+		msg.WriteString(fmt.Sprintf("   單次檢查超時無效: %d\n", c.Global.CycleTimeout))
+	}
+	if c.Cloudflare.Timeout < 0 {
+		msg.WriteString(fmt.Sprintf("   Cloudflare 請求超時無效: %d\n", c.Cloudflare.Timeout))
+	}
+	if c.Cloudflare.ZoneCacheTTL < 0 {
+		msg.WriteString(fmt.Sprintf("   區域快取時間無效: %d\n", c.Cloudflare.ZoneCacheTTL))
+	}
+	if c.Cloudflare.Retry.MaxAttempts < 0 || c.Cloudflare.Retry.BaseDelay < 0 || c.Cloudflare.Retry.MaxDelay < 0 {
+		msg.WriteString("   Cloudflare 重試設定不能為負數\n")
+	}
+	if c.Cloudflare.RateLimit.Requests < 0 || c.Cloudflare.RateLimit.Window < 0 {
+		msg.WriteString("   Cloudflare 請求配額設定不能為負數\n")
+	}
+
+	if c.Cloudflare.Ownership.Enabled {
+		switch c.Cloudflare.Ownership.Mode {
+		case OwnershipComment, OwnershipTag:
+		default:
+			msg.WriteString(fmt.Sprintf("   擁有者標記方式無效: %s (僅支援 comment 或 tag)\n", c.Cloudflare.Ownership.Mode))
+		}
+		if c.Cloudflare.Ownership.OwnerID == "" || strings.ContainsAny(c.Cloudflare.Ownership.OwnerID, " \t") {
+			msg.WriteString(fmt.Sprintf("   擁有者識別碼無效: %q (不能為空或包含空白)\n", c.Cloudflare.Ownership.OwnerID))
+		}
+	}
+
+	c.validateRecordSets(&msg)
 	if len(c.DNSRecords) == 0 {
 		// return fmt.Errorf("未配置任何 DNS 記錄")
 		msg.WriteString("   未配置任何 DNS 記錄\n")
 	}
 
-	// 檢查 DNS 記錄的 TTL 設置
+	// 檢查 DNS 記錄的類型和 TTL 設置
+	seen := make(map[string]bool)
 	for _, record := range c.DNSRecords {
+		if key := record.Key(); seen[key] {
+			msg.WriteString(fmt.Sprintf("   記錄 %s 重複配置 (同名稱和類型的多筆記錄需要以不同的 match 區分)\n", key))
+		} else {
+			seen[key] = true
+		}
+		if record.Type != "A" && record.Type != "AAAA" {
+			msg.WriteString(fmt.Sprintf("   記錄 %s 的類型無效: %s (僅支援 A 或 AAAA)\n", record.Name, record.Type))
+		}
+		if record.Zone != "" && !IsInZone(record.Name, record.Zone) {
+			msg.WriteString(fmt.Sprintf("   記錄 %s 不屬於區域 %s\n", record.Name, record.Zone))
+		}
 		if record.TTL != 1 && (record.TTL < 60 || record.TTL > 86400) {
 			// return fmt.Errorf("記錄 %s 的 TTL 值無效: %d (必須為 1=自動 或 60-86400 秒)", record.Name, record.TTL)
 			msg.WriteString(fmt.Sprintf("   記錄 %s 的 TTL 值無效: %d (必須為 1=自動 或 60-86400 秒)\n", record.Name, record.TTL))
 		}
+		switch record.Drift {
+		case DriftEnforce, DriftWarn, DriftIgnore:
+		default:
+			msg.WriteString(fmt.Sprintf("   記錄 %s 的 drift 無效: %s (僅支援 enforce、warn 或 ignore)\n", record.Name, record.Drift))
+		}
 	}
 
 	// 檢查 Webhook 配置
@@ -220,12 +657,22 @@ func (c *Config) Validate() error {
 	}
 
 	if msg.String() != "" {
-		return fmt.Errorf(msg.String())
+		return errors.New(msg.String())
 	} else {
 		return nil
 	}
 }
 
+// 檢查是否配置了指定類型的記錄
+func (c *Config) HasRecordType(recordType string) bool {
+	for _, record := range c.DNSRecords {
+		if record.Type == recordType {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) HasChanged() (bool, error) {
 	info, err := os.Stat(c.ConfigPath)
 	if err != nil {
@@ -234,15 +681,6 @@ func (c *Config) HasChanged() (bool, error) {
 	return info.ModTime().After(c.LastModified), nil
 }
 
-func (c *Config) Reload() error {
-	newConfig, err := LoadConfig(c.ConfigPath)
-	if err != nil {
-		return err
-	}
-	*c = *newConfig
-	return nil
-}
-
 func GetDefaultConfigPath() string {
 	if _, err := os.Stat("config.yaml"); err == nil {
 		return "config.yaml"
@@ -255,11 +693,26 @@ func GetDefaultConfigPath() string {
 	return "config.yaml"
 }
 
-// 從 DNS 記錄名稱中提取主域名
+// 從 DNS 記錄名稱中提取主域名（依據公共後綴列錶，例如 home.example.co.uk -> example.co.uk）
 func ExtractZoneNameFromDNS(dnsName string) string {
+	dnsName = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(dnsName), "."))
+	// 萬用字元記錄（*.example.com）以其父域名計算
+	dnsName = strings.TrimPrefix(dnsName, "*.")
+
+	if zoneName, err := publicsuffix.EffectiveTLDPlusOne(dnsName); err == nil {
+		return zoneName
+	}
+
 	parts := strings.Split(dnsName, ".")
 	if len(parts) >= 2 {
 		return parts[len(parts)-2] + "." + parts[len(parts)-1]
 	}
 	return dnsName
 }
+
+// 檢查記錄名稱是否屬於指定區域
+func IsInZone(recordName, zoneName string) bool {
+	recordName = strings.ToLower(strings.TrimSuffix(recordName, "."))
+	zoneName = strings.ToLower(strings.TrimSuffix(zoneName, "."))
+	return recordName == zoneName || strings.HasSuffix(recordName, "."+zoneName)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 記錄模板：展開為 子網域 × 區域 × 類型 的記錄，共用 proxied / ttl 等設定
+type RecordSet struct {
+	Subdomains      []string `yaml:"subdomains"` // 子網域，"@" 表示區域本身，"*" 為萬用字元記錄
+	Zones           []string `yaml:"zones"`      // 區域名稱
+	Types           []string `yaml:"types"`      // 記錄類型，預設為 A
+	Proxied         bool     `yaml:"proxied"`
+	TTL             int      `yaml:"ttl"` // 預設為 1（自動）
+	CreateIfMissing bool     `yaml:"create_if_missing"`
+	Drift           string   `yaml:"drift"`
+	Takeover        bool     `yaml:"takeover"`
+}
+
+// 展開記錄模板並加入記錄列錶；與 dns_records 中相同的記錄以 dns_records 為準
+func (c *Config) expandRecordSets() {
+	seen := make(map[string]bool)
+	for i := range c.DNSRecords {
+		seen[recordSetKey(c.DNSRecords[i].Name, c.DNSRecords[i].Type)] = true
+	}
+
+	for _, set := range c.RecordSets {
+		types := set.Types
+		if len(types) == 0 {
+			types = []string{"A"}
+		}
+		ttl := set.TTL
+		if ttl == 0 {
+			ttl = 1
+		}
+
+		for _, zone := range set.Zones {
+			zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+			for _, subdomain := range set.Subdomains {
+				name := recordSetName(subdomain, zone)
+				for _, recordType := range types {
+					key := recordSetKey(name, recordType)
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+
+					c.DNSRecords = append(c.DNSRecords, DNSRecord{
+						Name:            name,
+						Type:            recordType,
+						Proxied:         set.Proxied,
+						TTL:             ttl,
+						CreateIfMissing: set.CreateIfMissing,
+						Zone:            zone,
+						Drift:           set.Drift,
+						Takeover:        set.Takeover,
+						FromRecordSet:   true,
+					})
+				}
+			}
+		}
+	}
+}
+
+// 子網域和區域組成的記錄名稱
+func recordSetName(subdomain, zone string) string {
+	subdomain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(subdomain)), ".")
+	if subdomain == "@" || subdomain == "" {
+		return zone
+	}
+	return subdomain + "." + zone
+}
+
+// 比對重複記錄使用的鍵（記錄類型在展開時尚未統一為大寫，也尚未套用預設值 A）
+func recordSetKey(name, recordType string) string {
+	recordType = strings.ToUpper(strings.TrimSpace(recordType))
+	if recordType == "" {
+		recordType = "A"
+	}
+	return strings.ToLower(name) + "/" + recordType
+}
+
+// 檢查記錄模板的設置
+func (c *Config) validateRecordSets(msg *strings.Builder) {
+	for i, set := range c.RecordSets {
+		if len(set.Subdomains) == 0 || len(set.Zones) == 0 {
+			msg.WriteString(fmt.Sprintf("   記錄模板 %d 缺少 subdomains 或 zones\n", i+1))
+		}
+	}
+}
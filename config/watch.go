@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 預設的防抖時間：編輯器保存時常會連續觸發多個事件
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// 配置相關的檔案：配置文件、.env 和配置中引用的檔案
+func (c *Config) WatchedFiles() []string {
+	files := []string{c.ConfigPath, DotEnvPath}
+	if c.Cloudflare.CAFile != "" {
+		files = append(files, c.Cloudflare.CAFile)
+	}
+	return files
+}
+
+// 配置文件監視器：監視檔案所在的目錄（編輯器常以重命名方式保存，直接監視檔案會失效），
+// 只有被監視的檔案變更時才通知
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+	changes  chan struct{}
+	done     chan struct{}
+
+	mu    sync.Mutex
+	files map[string]bool // 被監視檔案的絕對路徑
+	dirs  map[string]bool // 已監視的目錄
+}
+
+func NewWatcher(files []string, debounce time.Duration) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("創建檔案監視器失敗: %w", err)
+	}
+
+	w := &Watcher{
+		watcher:  fsWatcher,
+		debounce: debounce,
+		changes:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+	}
+	if err := w.SetFiles(files); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// 設置要監視的檔案（重新加載配置後引用的檔案可能改變）
+func (w *Watcher) SetFiles(files []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.files = make(map[string]bool)
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return fmt.Errorf("解析路徑失敗 (%s): %w", file, err)
+		}
+		w.files[path] = true
+
+		dir := filepath.Dir(path)
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			return fmt.Errorf("監視目錄失敗 (%s): %w", dir, err)
+		}
+		w.dirs[dir] = true
+	}
+
+	return nil
+}
+
+// 檔案變更通知（已防抖，多次變更只通知一次）
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
+
+func (w *Watcher) watched(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.files[filepath.Clean(path)]
+}
+
+func (w *Watcher) run() {
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.watched(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Printf("⚠️  檔案監視錯誤: %v\n", err)
+
+		case <-timer.C:
+			select {
+			case w.changes <- struct{}{}:
+			default:
+				// 已有待處理的通知
+			}
+
+		case <-w.done:
+			timer.Stop()
+			return
+		}
+	}
+}
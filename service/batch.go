@@ -0,0 +1,93 @@
+package service
+
+import (
+	"cfddns/cloudflare"
+	"cfddns/config"
+	"context"
+	"fmt"
+	"os"
+)
+
+// 等待批次修改的記錄
+type batchUpdate struct {
+	record  config.DNSRecord
+	current CachedRecord
+	patch   cloudflare.RecordPatch
+	drift   []string
+}
+
+// 將有暫存記錄 ID 的記錄依區域分組，同一區域有多筆需要修改的記錄時以單一批次請求更新。
+// 回傳已更新的數量和需要逐筆處理的記錄（沒有暫存 ID、不需要修改或批次請求失敗）
+func (d *DDNSService) batchUpdateRecords(ctx context.Context, records []config.DNSRecord, newIP string) (int, []config.DNSRecord) {
+	var zones []string
+	groups := make(map[string][]batchUpdate)
+	for _, record := range records {
+		current, ok := d.cachedRecord(&record)
+		if !ok || current.ID == "" || current.ZoneID == "" {
+			continue
+		}
+		patch, drift, err := d.recordPatch(&record, current, newIP)
+		if err != nil || patch.Empty() {
+			continue
+		}
+		if _, seen := groups[current.ZoneID]; !seen {
+			zones = append(zones, current.ZoneID)
+		}
+		groups[current.ZoneID] = append(groups[current.ZoneID], batchUpdate{
+			record:  record,
+			current: current,
+			patch:   patch,
+			drift:   drift,
+		})
+	}
+
+	updatedCount := 0
+	batched := make(map[string]bool)
+	for _, zoneID := range zones {
+		group := groups[zoneID]
+		if len(group) < 2 {
+			// 只有一筆記錄時直接修改即可
+			continue
+		}
+		if err := d.applyBatch(ctx, zoneID, group, newIP); err != nil {
+			fmt.Printf("⚠️  批次更新 %d 個記錄失敗，改為逐筆更新: %v\n", len(group), err)
+			continue
+		}
+		for _, update := range group {
+			batched[recordKey(&update.record)] = true
+		}
+		updatedCount += len(group)
+	}
+
+	// 保持配置中的順序
+	var remaining []config.DNSRecord
+	for _, record := range records {
+		if !batched[recordKey(&record)] {
+			remaining = append(remaining, record)
+		}
+	}
+
+	return updatedCount, remaining
+}
+
+// 以單一請求修改同一區域的記錄
+func (d *DDNSService) applyBatch(ctx context.Context, zoneID string, group []batchUpdate, newIP string) error {
+	patches := make([]cloudflare.BatchPatch, len(group))
+	for i, update := range group {
+		patches[i] = cloudflare.BatchPatch{ID: update.current.ID, RecordPatch: update.patch}
+	}
+
+	fmt.Printf("📦 批次更新 %d 個記錄...\n", len(group))
+	cfRecords, err := d.cfClient.BatchPatchDNSRecords(ctx, zoneID, patches)
+	if err != nil {
+		return err
+	}
+
+	for i, update := range group {
+		warnDrift(os.Stdout, &update.record, update.drift)
+		printUpdate(os.Stdout, &update.record, update.current, newIP, update.patch)
+		d.recordUpdated(ctx, os.Stdout, &update.record, update.current, &cfRecords[i], newIP, update.patch)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 同時查詢多個 IP 來源，至少 min_agree 個回傳相同地址才採用，
+// 避免單一服務異常或被劫持時把所有記錄指向錯誤的地址；notify 為 false 時不發送通知
+func (d *DDNSService) consensusIP(ctx context.Context, family ipFamily, providers []ipProvider, notify bool) (string, error) {
+	consensus := d.config.Global.Consensus
+	// 無效的 providers 已由 Validate 回報，但服務仍會啟動，至少查詢一個服務
+	providers = providers[:min(len(providers), max(consensus.Providers, 1))]
+
+	type answer struct {
+		ip  string
+		err error
+	}
+	answers := make([]answer, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Go(func() {
+			ip, err := provider.GetIP(ctx, family)
+			answers[i] = answer{ip: ip, err: err}
+		})
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s 檢查已取消: %w", family.label, ctx.Err())
+	}
+
+	// 統計每個地址由哪些服務回傳
+	votes := make(map[string][]string)
+	var addresses []string
+	failed := 0
+	for i, answer := range answers {
+		if answer.err != nil {
+			failed++
+			if verbose {
+				fmt.Printf("   ❌ %v\n", answer.err)
+			}
+			continue
+		}
+		if verbose {
+			fmt.Printf("   %s: %s\n", providers[i], answer.ip)
+		}
+		if _, seen := votes[answer.ip]; !seen {
+			addresses = append(addresses, answer.ip)
+		}
+		votes[answer.ip] = append(votes[answer.ip], providers[i].String())
+	}
+
+	if len(addresses) > 1 {
+		d.reportDisagreement(ctx, family, addresses, votes, notify)
+	} else if notify {
+		d.setDisagreement(family, "")
+	}
+
+	best, tied := "", false
+	for _, ip := range addresses {
+		switch {
+		case len(votes[ip]) > len(votes[best]):
+			best, tied = ip, false
+		case len(votes[ip]) == len(votes[best]):
+			tied = true
+		}
+	}
+	if best == "" || tied || len(votes[best]) < consensus.MinAgree {
+		return "", fmt.Errorf("%s 檢查服務未達成共識: 需要 %d 個相同結果，最多 %d 個 (%d 個服務失敗)",
+			family.label, consensus.MinAgree, len(votes[best]), failed)
+	}
+
+	if verbose {
+		fmt.Printf("   ✅ %d/%d 個服務同意: %s\n", len(votes[best]), len(providers), best)
+	}
+	return best, nil
+}
+
+// 在標準錯誤顯示服務結果不一致的警告（避免混入 plan -o json 的輸出），
+// 結果與上次相同時不重複發送通知
+func (d *DDNSService) reportDisagreement(ctx context.Context, family ipFamily, addresses []string, votes map[string][]string, notify bool) {
+	parts := make([]string, len(addresses))
+	for i, ip := range addresses {
+		parts[i] = fmt.Sprintf("%s (%s)", ip, strings.Join(votes[ip], ", "))
+	}
+	description := strings.Join(parts, "; ")
+
+	fmt.Fprintf(os.Stderr, "⚠️  %s 檢查服務結果不一致: %s\n", family.label, description)
+	if notify && d.setDisagreement(family, description) {
+		d.webhook.SendWarning(context.WithoutCancel(ctx),
+			fmt.Sprintf("%s 檢查服務結果不一致: %s", family.label, description))
+	}
+}
+
+// 記錄目前的不一致情況，回傳是否與上次不同
+func (d *DDNSService) setDisagreement(family ipFamily, description string) bool {
+	d.disagreeMu.Lock()
+	defer d.disagreeMu.Unlock()
+
+	if d.disagreed == nil {
+		d.disagreed = make(map[string]string)
+	}
+	changed := d.disagreed[family.recordType] != description
+	d.disagreed[family.recordType] = description
+	return changed
+}
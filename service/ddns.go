@@ -4,41 +4,78 @@ import (
 	"cfddns/cloudflare"
 	"cfddns/config"
 	"cfddns/webhook"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type DDNSService struct {
-	config    *config.Config
-	cfClient  *cloudflare.CloudflareClient
-	webhook   *webhook.WebhookClient
-	currentIP string            // 當前的公共 IP
-	dnsIPs    map[string]string // 記錄名稱 -> DNS 記錄中的 IP
-	cacheFile string            // IP 暫存檔案路徑
-	stopChan  chan bool
-	lastCheck time.Time
-	nextCheck time.Time
+	config      *config.Config
+	cfClient    *cloudflare.CloudflareClient
+	webhook     *webhook.WebhookClient
+	currentIP   string                  // 當前的公共 IPv4
+	currentIPv6 string                  // 當前的公共 IPv6
+	records     map[string]CachedRecord // 記錄鍵 (名稱/類型) -> 最後已知的記錄狀態
+	recordsMu   sync.Mutex              // 並發更新記錄時保護 records
+	disagreed   map[string]string       // 記錄類型 -> 上次通知的 IP 檢查服務不一致情況
+	disagreeMu  sync.Mutex              // 保護 disagreed
+	cacheFile   string                  // IP 暫存檔案路徑
+	stopChan    chan struct{}           // 立即停止，中止進行中的請求
+	stopOnce    sync.Once
+	drainChan   chan struct{} // 完成當前檢查後停止
+	drainOnce   sync.Once
+	reloadChan  chan struct{} // 重新加載配置並立即檢查
+	once        bool          // 單次執行模式，沒有下次檢查
+	dryRun      bool          // 只顯示將要執行的操作，不修改記錄
+	lastCheck   time.Time
+	lastVerify  time.Time // 上次實際檢查 Cloudflare 記錄的時間
+	nextCheck   time.Time
 }
 
 // IP 暫存資料結構
 type IPCache struct {
 	LastIP     string            `json:"last_ip"`
+	LastIPv6   string            `json:"last_ipv6,omitempty"`
 	LastUpdate time.Time         `json:"last_update"`
-	DNSRecords map[string]string `json:"dns_records"` // 記錄名稱 -> 最後已知的 DNS IP
+	DNSRecords map[string]string `json:"dns_records"` // 記錄鍵 (名稱/類型) -> 最後已知的 DNS IP
+
+	Records map[string]CachedRecord `json:"records,omitempty"` // 記錄鍵 (名稱/類型) -> 最後已知的記錄狀態
+}
+
+// 暫存的記錄狀態，有記錄 ID 時更新記錄不需要先查詢
+type CachedRecord struct {
+	ID      string   `json:"id,omitempty"`
+	ZoneID  string   `json:"zone_id,omitempty"`
+	Content string   `json:"content"`
+	Proxied bool     `json:"proxied"`
+	TTL     int      `json:"ttl,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// 單次檢查結果
+type CheckResult struct {
+	Total   int // 檢查的記錄數
+	Updated int // 已更新或創建的記錄數
+	Failed  int // 失敗的記錄數
 }
 
 var verbose bool
 
-func NewDDNSService(cfg *config.Config) *DDNSService {
-	cfClient := cloudflare.NewClient(&cfg.Cloudflare)
+func NewDDNSService(cfg *config.Config) (*DDNSService, error) {
+	cfClient, err := cloudflare.NewClient(&cfg.Cloudflare)
+	if err != nil {
+		return nil, fmt.Errorf("創建 Cloudflare 客戶端失敗: %w", err)
+	}
 
 	webhookClient := webhook.NewClient(
 		cfg.Webhook.URL,
@@ -58,17 +95,20 @@ func NewDDNSService(cfg *config.Config) *DDNSService {
 		config:    cfg,
 		cfClient:  cfClient,
 		webhook:   webhookClient,
-		dnsIPs:    make(map[string]string),
+		records:   make(map[string]CachedRecord),
 		cacheFile: cacheFile,
-		stopChan:  make(chan bool),
-		lastCheck: now,
-		nextCheck: now.Add(time.Duration(cfg.Global.CheckInterval) * time.Second),
+		stopChan:  make(chan struct{}),
+		drainChan: make(chan struct{}),
+		// 緩衝一個請求，檢查進行中收到的多次重新加載會合併為一次
+		reloadChan: make(chan struct{}, 1),
+		lastCheck:  now,
+		nextCheck:  now.Add(time.Duration(cfg.Global.CheckInterval) * time.Second),
 	}
 
 	// 載入暫存的 IP 資料
 	service.loadIPCache()
 
-	return service
+	return service, nil
 }
 
 // 取得暫存檔案路徑
@@ -82,19 +122,27 @@ func getCacheFilePath() string {
 	return "ip_cache.json"
 }
 
-// 等待網路連線
-func waitForNetwork() {
-	time.Sleep(5 * time.Second)
+// 等待網路連線，context 結束時放棄等待
+func waitForNetwork(ctx context.Context) error {
+	if err := cloudflare.SleepContext(ctx, 5*time.Second); err != nil {
+		return err
+	}
 
+	dialer := &net.Dialer{Timeout: 3 * time.Second}
 	for {
-		conn, err := net.DialTimeout("tcp", "1.1.1.1:53", 3*time.Second)
+		conn, err := dialer.DialContext(ctx, "tcp", "1.1.1.1:53")
 		if err == nil {
 			conn.Close()
 			fmt.Println("network ready")
-			return
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 		fmt.Println("network not ready, retrying...")
-		time.Sleep(2 * time.Second)
+		if err := cloudflare.SleepContext(ctx, 2*time.Second); err != nil {
+			return err
+		}
 	}
 }
 
@@ -128,16 +176,34 @@ func (d *DDNSService) loadIPCache() {
 	}
 
 	d.currentIP = cache.LastIP
-	d.dnsIPs = cache.DNSRecords
+	d.currentIPv6 = cache.LastIPv6
+	for key, ip := range cache.DNSRecords {
+		// 舊版暫存以記錄名稱為鍵，無法區分 A / AAAA，直接忽略
+		if strings.Contains(key, "/") {
+			d.records[key] = CachedRecord{Content: ip}
+		}
+	}
+	for key, record := range cache.Records {
+		if strings.Contains(key, "/") {
+			d.records[key] = record
+		}
+	}
 
 	if verbose {
 		fmt.Printf("📁 載入暫存 IP: %s\n", d.currentIP)
-		fmt.Printf("📋 暫存記錄數量: %d\n", len(d.dnsIPs))
+		if d.currentIPv6 != "" {
+			fmt.Printf("📁 載入暫存 IPv6: %s\n", d.currentIPv6)
+		}
+		fmt.Printf("📋 暫存記錄數量: %d\n", len(d.records))
 	}
 }
 
 // 儲存 IP 暫存資料
 func (d *DDNSService) saveIPCache() { // 修正：移除多餘的 N
+	if d.dryRun {
+		return
+	}
+
 	// 確保暫存目錄存在
 	cacheDir := filepath.Dir(d.cacheFile)
 	if cacheDir != "." {
@@ -149,8 +215,10 @@ func (d *DDNSService) saveIPCache() { // 修正：移除多餘的 N
 
 	cache := IPCache{
 		LastIP:     d.currentIP,
+		LastIPv6:   d.currentIPv6,
 		LastUpdate: time.Now(),
-		DNSRecords: d.dnsIPs,
+		DNSRecords: d.dnsIPsSnapshot(),
+		Records:    d.recordsSnapshot(),
 	}
 
 	data, err := json.MarshalIndent(cache, "", "  ")
@@ -166,6 +234,9 @@ func (d *DDNSService) saveIPCache() { // 修正：移除多餘的 N
 
 	if verbose {
 		fmt.Printf("💾 暫存 IP 資料: %s\n", d.currentIP)
+		if d.currentIPv6 != "" {
+			fmt.Printf("💾 暫存 IPv6 資料: %s\n", d.currentIPv6)
+		}
 	}
 }
 
@@ -173,138 +244,162 @@ func SetVerbose(v bool) {
 	verbose = v
 }
 
-func (d *DDNSService) GetCurrentIP() (string, error) {
-	var lastErr error
+// 暫存中記錄的鍵值（同名的 A 和 AAAA 記錄、以 match 區分的記錄需要分開暫存）
+func recordKey(record *config.DNSRecord) string {
+	return record.Key()
+}
 
-	if verbose {
-		fmt.Printf("🔍 正在檢查公共 IP...\n")
-	}
+// 讀取暫存中的記錄狀態
+func (d *DDNSService) cachedRecord(record *config.DNSRecord) (CachedRecord, bool) {
+	d.recordsMu.Lock()
+	defer d.recordsMu.Unlock()
+	cached, ok := d.records[recordKey(record)]
+	return cached, ok
+}
 
-	for i, url := range d.config.Global.IPCheckURLs {
-		if verbose {
-			fmt.Printf("   嘗試服務 %d: %s\n", i+1, url)
-		}
+// 以 Cloudflare 回傳的記錄更新暫存
+func (d *DDNSService) storeRecord(record *config.DNSRecord, cfRecord *cloudflare.DNSRecord) CachedRecord {
+	cached := CachedRecord{
+		ID:      cfRecord.ID,
+		ZoneID:  cfRecord.ZoneID,
+		Content: cfRecord.Content,
+		Proxied: cfRecord.Proxied,
+		TTL:     cfRecord.TTL,
+		Comment: cfRecord.Comment,
+		Tags:    cfRecord.Tags,
+	}
 
-		resp, err := http.Get(url)
-		if err != nil {
-			lastErr = fmt.Errorf("服務 %s 失敗: %w", url, err)
-			if verbose {
-				fmt.Printf("   ❌ %v\n", lastErr)
-			}
-			continue
-		}
-		defer resp.Body.Close()
+	d.recordsMu.Lock()
+	defer d.recordsMu.Unlock()
+	d.records[recordKey(record)] = cached
+	return cached
+}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = fmt.Errorf("讀取響應失敗: %w", err)
-			if verbose {
-				fmt.Printf("   ❌ %v\n", lastErr)
-			}
-			continue
-		}
+// 移除暫存的記錄（例如記錄 ID 已失效）
+func (d *DDNSService) forgetRecord(key string) {
+	d.recordsMu.Lock()
+	defer d.recordsMu.Unlock()
+	delete(d.records, key)
+}
 
-		ip := strings.TrimSpace(string(body))
-		if isValidIP(ip) {
-			if verbose {
-				fmt.Printf("   ✅ 從 %s 獲取到有效 IP: %s\n", url, ip)
-			}
-			return ip, nil
-		}
+// 讀取暫存中記錄的 DNS IP
+func (d *DDNSService) dnsIP(record *config.DNSRecord) (string, bool) {
+	cached, ok := d.cachedRecord(record)
+	return cached.Content, ok
+}
 
-		lastErr = fmt.Errorf("從 %s 獲取的 IP 無效: %s", url, ip)
-		if verbose {
-			fmt.Printf("   ❌ %v\n", lastErr)
-		}
+// 各記錄的 DNS IP，供儲存和顯示使用
+func (d *DDNSService) dnsIPsSnapshot() map[string]string {
+	d.recordsMu.Lock()
+	defer d.recordsMu.Unlock()
+	ips := make(map[string]string, len(d.records))
+	for key, cached := range d.records {
+		ips[key] = cached.Content
 	}
+	return ips
+}
 
-	return "", fmt.Errorf("所有 IP 檢查服務都失敗: %w", lastErr)
+// 複製暫存的記錄狀態
+func (d *DDNSService) recordsSnapshot() map[string]CachedRecord {
+	d.recordsMu.Lock()
+	defer d.recordsMu.Unlock()
+	return maps.Clone(d.records)
 }
 
-// 檢查 IP 地址是否有效
-func isValidIP(ip string) bool {
-	if ip == "" {
-		return false
+// 取得暫存的公共 IP
+func (d *DDNSService) cachedIP(family ipFamily) string {
+	if family == familyIPv6 {
+		return d.currentIPv6
 	}
+	return d.currentIP
+}
 
-	// 簡單的 IPv4 驗證
-	parts := strings.Split(ip, ".")
-	if len(parts) != 4 {
-		return false
+// 更新暫存的公共 IP
+func (d *DDNSService) setCachedIP(family ipFamily, ip string) {
+	if family == familyIPv6 {
+		d.currentIPv6 = ip
+	} else {
+		d.currentIP = ip
 	}
+}
 
-	for _, part := range parts {
-		if len(part) == 0 || len(part) > 3 {
-			return false
-		}
-
-		// 檢查是否為數字
-		for _, char := range part {
-			if char < '0' || char > '9' {
-				return false
-			}
-		}
-
-		// 檢查數字範圍
-		num, err := strconv.Atoi(part)
-		if err != nil || num < 0 || num > 255 {
-			return false
+// 取得配置中使用到的協議族
+func (d *DDNSService) activeFamilies() []ipFamily {
+	var families []ipFamily
+	for _, family := range []ipFamily{familyIPv4, familyIPv6} {
+		if d.config.HasRecordType(family.recordType) {
+			families = append(families, family)
 		}
+	}
+	return families
+}
 
-		// 檢查前導零（但允許 "0"）
-		if len(part) > 1 && part[0] == '0' {
-			return false
+// 取得屬於指定協議族的記錄
+func (d *DDNSService) recordsOf(family ipFamily) []config.DNSRecord {
+	var records []config.DNSRecord
+	for _, record := range d.config.DNSRecords {
+		if record.Type == family.recordType {
+			records = append(records, record)
 		}
 	}
+	return records
+}
 
-	return true
+// 單次檢查的最長時間
+func (d *DDNSService) cycleTimeout() time.Duration {
+	if d.config.Global.CycleTimeout <= 0 {
+		return 120 * time.Second
+	}
+	return time.Duration(d.config.Global.CycleTimeout) * time.Second
 }
 
-func (d *DDNSService) UpdateDNSRecords() error {
+func (d *DDNSService) UpdateDNSRecords(ctx context.Context) (CheckResult, error) {
+	// 每次檢查設置截止時間，避免單次檢查卡住後續檢查
+	ctx, cancel := context.WithTimeout(ctx, d.cycleTimeout())
+	defer cancel()
+
 	now := time.Now()
 	d.lastCheck = now
 	d.nextCheck = now.Add(time.Duration(d.config.Global.CheckInterval) * time.Second)
 
-	// 獲取當前公共 IP
-	currentIP, err := d.GetCurrentIP()
-	if err != nil {
-		return fmt.Errorf("獲取當前 IP 失敗: %w", err)
+	if d.dryRun {
+		return d.dryRunCheck(ctx)
 	}
 
-	// 檢查 IP 是否發生變化
-	ipChanged := d.currentIP != currentIP
-	if ipChanged {
-		fmt.Printf("🌐 檢測到 IP 變化: %s → %s\n", d.currentIP, currentIP)
-		d.currentIP = currentIP
+	totalCount := 0
+	updatedCount := 0
+	failureCount := 0
+	var ipErrors []string
 
-		// IP 變化時才需要更新 DNS 記錄
-		return d.updateAllDNSRecords(currentIP)
-	} else {
-		// IP 未變化，只檢查 DNS 記錄同步狀態
-		if verbose {
-			fmt.Printf("💤 公共 IP 未變化: %s\n", currentIP)
+	for _, family := range d.activeFamilies() {
+		records := d.recordsOf(family)
+		totalCount += len(records)
+
+		// 獲取當前公共 IP
+		currentIP, err := d.getCurrentIP(ctx, family)
+		if err != nil {
+			failureCount += len(records)
+			ipErrors = append(ipErrors, fmt.Sprintf("獲取當前 %s 失敗: %v", family.label, err))
+			continue
 		}
-		return d.verifyDNSRecordsSync(currentIP)
-	}
-}
 
-// 更新所有 DNS 記錄（只有 IP 變化時呼叫）
-func (d *DDNSService) updateAllDNSRecords(newIP string) error {
-	successCount := 0
-	failureCount := 0
-	updatedCount := 0
+		// 檢查 IP 是否發生變化
+		var updated, failed int
+		if previousIP := d.cachedIP(family); previousIP != currentIP {
+			fmt.Printf("🌐 檢測到 %s 變化: %s → %s\n", family.label, previousIP, currentIP)
+			d.setCachedIP(family, currentIP)
 
-	for _, record := range d.config.DNSRecords {
-		updated, err := d.updateSingleRecord(&record, newIP)
-		if err != nil {
-			failureCount++
-			fmt.Printf("❌ 更新記錄 %s 失敗: %v\n", record.Name, err)
+			// IP 變化時才需要更新 DNS 記錄
+			updated, failed = d.updateRecords(ctx, records, currentIP)
 		} else {
-			successCount++
-			if updated {
-				updatedCount++
+			// IP 未變化，只檢查 DNS 記錄同步狀態
+			if verbose {
+				fmt.Printf("💤 公共 %s 未變化: %s\n", family.label, currentIP)
 			}
+			updated, failed = d.verifyDNSRecordsSync(ctx, records, currentIP)
 		}
+		updatedCount += updated
+		failureCount += failed
 	}
 
 	// 顯示檢查結果和下次檢查時間
@@ -313,123 +408,163 @@ func (d *DDNSService) updateAllDNSRecords(newIP string) error {
 	// 儲存暫存資料
 	d.saveIPCache()
 
+	result := CheckResult{Total: totalCount, Updated: updatedCount, Failed: failureCount}
+
+	if len(ipErrors) > 0 {
+		return result, errors.New(strings.Join(ipErrors, "; "))
+	}
+
 	if failureCount > 0 {
-		return fmt.Errorf("部分記錄更新失敗: %d 成功, %d 失敗", successCount, failureCount)
+		return result, fmt.Errorf("部分記錄更新失敗: %d 成功, %d 失敗", totalCount-failureCount, failureCount)
 	}
 
-	return nil
+	return result, nil
 }
 
-// 驗證 DNS 記錄是否同步（IP 未變化時呼叫）
-func (d *DDNSService) verifyDNSRecordsSync(currentIP string) error {
-	outOfSyncRecords := []string{}
-	needsUpdate := false
+// 啟用 dry-run 模式：每次檢查只顯示將要執行的操作
+func (d *DDNSService) SetDryRun(dryRun bool) {
+	d.dryRun = dryRun
+}
 
-	for _, record := range d.config.DNSRecords {
-		// 檢查暫存中的 DNS IP 是否與當前 IP 一致
-		cachedDNSIP, exists := d.dnsIPs[record.Name]
-		if !exists || cachedDNSIP != currentIP {
-			// 暫存資料不一致，需要實際檢查 Cloudflare
-			actualDNSIP, err := d.cfClient.GetDNSRecordIP(record.Name, record.Type)
-			if err != nil {
-				fmt.Printf("⚠️  檢查記錄 %s 同步狀態失敗: %v\n", record.Name, err)
-				continue
-			}
+// 執行單次檢查（不等待網路、不進入監控循環），適用於 cron 或 systemd timer
+func (d *DDNSService) RunOnce(ctx context.Context) (CheckResult, error) {
+	d.once = true
+	return d.UpdateDNSRecords(ctx)
+}
 
-			// 更新暫存
-			d.dnsIPs[record.Name] = actualDNSIP
+// 更新指定的 DNS 記錄，回傳已更新和失敗的數量
+func (d *DDNSService) updateRecords(ctx context.Context, records []config.DNSRecord, newIP string) (int, int) {
+	// 擁有者可能已被其他實例接管，先以 Cloudflare 中的記錄更新暫存
+	d.refreshOwners(ctx, records)
 
-			// 檢查是否同步
-			if actualDNSIP != currentIP {
-				outOfSyncRecords = append(outOfSyncRecords, record.Name)
-				needsUpdate = true
-				if verbose {
-					fmt.Printf("⚠️  記錄 %s 不同步: %s ≠ %s\n", record.Name, actualDNSIP, currentIP)
-				}
-			} else {
-				// 實際檢查發現已同步，更新暫存
-				if verbose {
-					fmt.Printf("✅ 記錄 %s 已同步 (實際檢查)\n", record.Name)
-				}
-			}
-		} else {
-			// 暫存資料顯示已同步
-			if verbose {
-				fmt.Printf("✅ 記錄 %s 已同步 (暫存驗證)\n", record.Name)
-			}
-		}
+	// 同一區域的多筆記錄先以批次請求更新，其餘記錄逐筆更新
+	updatedCount, records := d.batchUpdateRecords(ctx, records, newIP)
+	failureCount := 0
+
+	type updateResult struct {
+		updated bool
+		err     error
 	}
+	results := forEachConcurrent(d.concurrency(), records, func(out io.Writer, record config.DNSRecord) updateResult {
+		updated, err := d.updateSingleRecord(ctx, out, &record, newIP)
+		if err != nil {
+			fmt.Fprintf(out, "❌ 更新記錄 %s 失敗: %v\n", record.Name, err)
+		}
+		return updateResult{updated: updated, err: err}
+	})
 
-	// 如果有不同步的記錄，進行更新
-	if needsUpdate {
-		fmt.Printf("⚠️  發現 %d 個不同步的記錄，進行更新...\n", len(outOfSyncRecords))
-		for _, recordName := range outOfSyncRecords {
-			fmt.Printf("   - %s\n", recordName)
+	for _, result := range results {
+		if result.err != nil {
+			failureCount++
+		} else if result.updated {
+			updatedCount++
 		}
-		return d.updateOutOfSyncRecords(currentIP, outOfSyncRecords)
 	}
 
-	// 顯示檢查結果
-	d.printCheckResult(0, 0) // 沒有更新，沒有失敗
-
-	// 儲存暫存資料
-	d.saveIPCache()
-
-	return nil
+	return updatedCount, failureCount
 }
 
-// 更新不同步的記錄（只更新指定的記錄）
-func (d *DDNSService) updateOutOfSyncRecords(newIP string, recordNames []string) error {
-	successCount := 0
-	failureCount := 0
-	updatedCount := 0
+// 驗證 DNS 記錄是否同步（IP 未變化時呼叫），不同步的記錄會立即更新
+func (d *DDNSService) verifyDNSRecordsSync(ctx context.Context, records []config.DNSRecord, currentIP string) (int, int) {
+	var outOfSyncRecords []config.DNSRecord
 
-	// 只更新不同步的記錄
-	for _, recordName := range recordNames {
-		// 找到對應的記錄配置
-		var recordConfig *config.DNSRecord
-		for _, record := range d.config.DNSRecords {
-			if record.Name == recordName {
-				recordConfig = &record
-				break
-			}
+	// 定期忽略暫存實際檢查，以發現在控制台中被修改的記錄
+	fullVerify := d.shouldVerify()
+
+	type syncResult struct {
+		inSync bool
+		err    error
+	}
+	results := forEachConcurrent(d.concurrency(), records, func(out io.Writer, record config.DNSRecord) syncResult {
+		inSync, err := d.checkRecordSync(ctx, out, &record, currentIP, fullVerify)
+		if err != nil {
+			fmt.Fprintf(out, "❌ 檢查記錄 %s 同步狀態失敗: %v\n", record.Name, err)
 		}
+		return syncResult{inSync: inSync, err: err}
+	})
 
-		if recordConfig == nil {
-			fmt.Printf("❌ 找不到記錄配置: %s\n", recordName)
+	failureCount := 0
+	for i, record := range records {
+		if results[i].err != nil {
 			failureCount++
-			continue
+		} else if !results[i].inSync {
+			outOfSyncRecords = append(outOfSyncRecords, record)
 		}
+	}
 
-		updated, err := d.updateSingleRecord(recordConfig, newIP)
-		if err != nil {
-			failureCount++
-			fmt.Printf("❌ 更新記錄 %s 失敗: %v\n", recordName, err)
-		} else {
-			successCount++
-			if updated {
-				updatedCount++
-			}
+	if fullVerify {
+		d.lastVerify = time.Now()
+	}
+
+	// 如果有不同步的記錄，進行更新
+	if len(outOfSyncRecords) > 0 {
+		fmt.Printf("⚠️  發現 %d 個不同步的記錄，進行更新...\n", len(outOfSyncRecords))
+		for _, record := range outOfSyncRecords {
+			fmt.Printf("   - %s (%s)\n", record.Name, record.Type)
 		}
+		updated, failed := d.updateRecords(ctx, outOfSyncRecords, currentIP)
+		return updated, failureCount + failed
 	}
 
-	// 顯示檢查結果和下次檢查時間
-	d.printCheckResult(updatedCount, failureCount)
+	return 0, failureCount
+}
 
-	// 儲存暫存資料
-	d.saveIPCache()
+// 檢查單一記錄是否同步，不同步時回傳 false；暫存顯示已同步且不需要定期檢查時不發送請求。
+// 無法查詢記錄（或記錄不存在且未設置 create_if_missing）時回傳錯誤
+func (d *DDNSService) checkRecordSync(ctx context.Context, out io.Writer, record *config.DNSRecord, currentIP string, fullVerify bool) (bool, error) {
+	// 檢查暫存中的 DNS IP 是否與當前 IP 一致
+	cachedDNSIP, exists := d.dnsIP(record)
+	if !fullVerify && exists && SameIP(cachedDNSIP, currentIP) {
+		// 暫存資料顯示已同步
+		if verbose {
+			fmt.Fprintf(out, "✅ 記錄 %s 已同步 (暫存驗證)\n", record.Name)
+		}
+		return true, nil
+	}
 
-	if failureCount > 0 {
-		return fmt.Errorf("部分記錄更新失敗: %d 成功, %d 失敗", successCount, failureCount)
+	// 暫存資料不一致或需要定期檢查，實際檢查 Cloudflare
+	cfRecord, err := d.cfClient.GetDNSRecord(ctx, record)
+	if errors.Is(err, cloudflare.ErrRecordNotFound) && record.CreateIfMissing {
+		// 記錄不存在，交由更新流程創建
+		if verbose {
+			fmt.Fprintf(out, "⚠️  記錄 %s 不存在，將自動創建\n", record.Name)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	// 更新暫存
+	current := d.storeRecord(record, cfRecord)
+
+	// 檢查是否同步
+	patch, drift, err := d.recordPatch(record, current, currentIP)
+	switch {
+	case err != nil:
+		// 記錄由其他實例管理，交由更新流程回報錯誤
+		return false, nil
+	case patch.Content != nil:
+		if verbose {
+			fmt.Fprintf(out, "⚠️  記錄 %s 不同步: %s ≠ %s\n", record.Name, cfRecord.Content, currentIP)
+		}
+		return false, nil
+	case !patch.Empty():
+		if verbose {
+			fmt.Fprintf(out, "⚠️  記錄 %s 設定與配置不同: %s\n", record.Name, describeChanges(current, patch))
+		}
+		return false, nil
+	default:
+		warnDrift(out, record, drift)
+		if verbose {
+			fmt.Fprintf(out, "✅ 記錄 %s 已同步 (實際檢查)\n", record.Name)
+		}
+		return true, nil
+	}
 }
 
 // 顯示檢查結果和下次檢查時間
 func (d *DDNSService) printCheckResult(updatedCount, failureCount int) {
-	now := time.Now()
-
 	// 顯示基本結果
 	if updatedCount > 0 {
 		fmt.Printf("✅ 檢查完成: %d 個記錄已更新", updatedCount)
@@ -439,7 +574,17 @@ func (d *DDNSService) printCheckResult(updatedCount, failureCount int) {
 		fmt.Printf("✅ 檢查完成: 所有記錄已同步")
 	}
 
-	// 顯示下次檢查時間
+	d.printNextCheck()
+}
+
+// 顯示下次檢查時間（並結束當前行）
+func (d *DDNSService) printNextCheck() {
+	if d.once {
+		fmt.Println()
+		return
+	}
+
+	now := time.Now()
 	timeUntilNext := d.nextCheck.Sub(now)
 	if timeUntilNext > 0 {
 		fmt.Printf(" | 下次檢查: %s (%.0f秒後)\n",
@@ -450,89 +595,184 @@ func (d *DDNSService) printCheckResult(updatedCount, failureCount int) {
 	}
 }
 
-func (d *DDNSService) updateSingleRecord(record *config.DNSRecord, newIP string) (bool, error) {
-	// 獲取記錄當前的 DNS IP
-	currentDNSIP, err := d.cfClient.GetDNSRecordIP(record.Name, record.Type)
-	if err != nil {
-		errorMsg := fmt.Sprintf("獲取當前 DNS IP 失敗: %v", err)
-		d.webhook.SendFailure(record.Name, errorMsg)
-		return false, fmt.Errorf("獲取記錄 %s 的當前 IP 失敗: %w", record.Name, err)
+// 更新單一記錄：有暫存的記錄 ID 時直接以 PATCH 修改需要變更的欄位，否則先查詢記錄
+func (d *DDNSService) updateSingleRecord(ctx context.Context, out io.Writer, record *config.DNSRecord, newIP string) (bool, error) {
+	current, cached := d.cachedRecord(record)
+	if !cached || current.ID == "" {
+		// 暫存中沒有記錄 ID，獲取記錄在 Cloudflare 中的當前狀態
+		cfRecord, err := d.cfClient.GetDNSRecord(ctx, record)
+		if errors.Is(err, cloudflare.ErrRecordNotFound) && record.CreateIfMissing {
+			return d.createRecord(ctx, out, record, newIP)
+		}
+		if err != nil {
+			errorMsg := fmt.Sprintf("獲取當前 DNS IP 失敗: %v", err)
+			d.webhook.SendFailure(context.WithoutCancel(ctx), record.Name, errorMsg)
+			return false, fmt.Errorf("獲取記錄 %s 的當前 IP 失敗: %w", record.Name, err)
+		}
+		current = d.storeRecord(record, cfRecord)
+		cached = false
 	}
 
-	// 檢查是否需要更新
-	if currentDNSIP == newIP {
+	// 檢查需要修改的欄位
+	patch, drift, err := d.recordPatch(record, current, newIP)
+	if err != nil {
+		d.webhook.SendFailure(context.WithoutCancel(ctx), record.Name, err.Error())
+		return false, err
+	}
+	warnDrift(out, record, drift)
+	if patch.Empty() {
 		if verbose {
-			fmt.Printf("✅ 記錄 %s 已是最新 IP: %s\n", record.Name, newIP)
+			fmt.Fprintf(out, "✅ 記錄 %s 已是最新 IP: %s\n", record.Name, newIP)
 		}
-		d.dnsIPs[record.Name] = newIP
 		return false, nil // 已經是最新 IP，不需要更新
 	}
 
 	// DNS 記錄不同步，需要更新
-	fmt.Printf("🔄 更新記錄 %s: %s → %s\n", record.Name, currentDNSIP, newIP)
+	printUpdate(out, record, current, newIP, patch)
 
-	// 獲取記錄 ID
-	recordID, err := d.cfClient.GetDNSRecordID(record.Name, record.Type)
-	if err != nil {
-		errorMsg := fmt.Sprintf("獲取記錄 ID 失敗: %v", err)
-		d.webhook.SendFailure(record.Name, errorMsg)
-		return false, fmt.Errorf("獲取記錄 ID 失敗 (%s): %w", record.Name, err)
+	// 只修改變更的欄位（drift 為 warn / ignore 時不修改 proxied / ttl）
+	cfRecord, err := d.cfClient.PatchDNSRecord(ctx, current.ZoneID, current.ID, patch)
+	if cached && cloudflare.IsNotFound(err) {
+		// 暫存的記錄 ID 已失效（記錄可能被刪除或重建），重新查詢後再更新
+		if verbose {
+			fmt.Fprintf(out, "⚠️  記錄 %s 的暫存 ID 已失效，重新查詢\n", record.Name)
+		}
+		d.forgetRecord(recordKey(record))
+		return d.updateSingleRecord(ctx, out, record, newIP)
 	}
-
-	// 更新記錄
-	if err := d.cfClient.UpdateDNSRecord(recordID, record, newIP); err != nil {
+	if err != nil {
 		errorMsg := fmt.Sprintf("更新記錄失敗: %v", err)
-		d.webhook.SendFailure(record.Name, errorMsg)
+		d.webhook.SendFailure(context.WithoutCancel(ctx), record.Name, errorMsg)
 		return false, fmt.Errorf("更新 DNS 記錄失敗 (%s): %w", record.Name, err)
 	}
 
+	d.recordUpdated(ctx, out, record, current, cfRecord, newIP, patch)
+	return true, nil
+}
+
+// 顯示將要進行的更新
+func printUpdate(out io.Writer, record *config.DNSRecord, current CachedRecord, newIP string, patch cloudflare.RecordPatch) {
+	if patch.Content == nil {
+		fmt.Fprintf(out, "🔧 修正記錄 %s 設定: %s\n", record.Name, describeChanges(current, patch))
+	} else {
+		fmt.Fprintf(out, "🔄 更新記錄 %s: %s → %s\n", record.Name, current.Content, newIP)
+	}
+}
+
+// 記錄更新成功後更新本地暫存並發送通知
+func (d *DDNSService) recordUpdated(ctx context.Context, out io.Writer, record *config.DNSRecord, previous CachedRecord, cfRecord *cloudflare.DNSRecord, newIP string, patch cloudflare.RecordPatch) {
+	d.storeRecord(record, cfRecord)
+	if patch.Content == nil {
+		d.webhook.SendInfo(context.WithoutCancel(ctx),
+			fmt.Sprintf("記錄 %s 設定已更新: %s", record.Name, describeChanges(previous, patch)))
+		fmt.Fprintf(out, "✅ 成功修正記錄 %s\n", record.Name)
+	} else {
+		d.webhook.SendSuccess(context.WithoutCancel(ctx), previous.Content, newIP, record.Name)
+		fmt.Fprintf(out, "✅ 成功更新記錄 %s → %s\n", record.Name, newIP)
+	}
+}
+
+// 創建不存在的記錄（需啟用 create_if_missing）
+func (d *DDNSService) createRecord(ctx context.Context, out io.Writer, record *config.DNSRecord, newIP string) (bool, error) {
+	fmt.Fprintf(out, "🆕 創建記錄 %s (%s) → %s\n", record.Name, record.Type, newIP)
+
+	comment, tags := d.newRecordLabels(record)
+	cfRecord, err := d.cfClient.CreateDNSRecord(ctx, record, newIP, comment, tags)
+	if err != nil {
+		errorMsg := fmt.Sprintf("創建記錄失敗: %v", err)
+		d.webhook.SendFailure(context.WithoutCancel(ctx), record.Name, errorMsg)
+		return false, fmt.Errorf("創建 DNS 記錄失敗 (%s): %w", record.Name, err)
+	}
+
 	// 更新本地暫存
-	d.dnsIPs[record.Name] = newIP
-	d.webhook.SendSuccess(currentDNSIP, newIP, record.Name)
-	fmt.Printf("✅ 成功更新記錄 %s → %s\n", record.Name, newIP)
+	d.storeRecord(record, cfRecord)
+	d.webhook.SendSuccess(context.WithoutCancel(ctx), "無", newIP, record.Name)
+	fmt.Fprintf(out, "✅ 成功創建記錄 %s → %s\n", record.Name, newIP)
 
 	return true, nil
 }
 
-func (d *DDNSService) Start() error {
-	// 等待網路連線
-	waitForNetwork()
+func (d *DDNSService) Start(ctx context.Context) error {
+	// 收到停止信號時取消所有進行中的請求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-d.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// 等待網路連線，等待期間收到停止請求時直接退出
+	netCtx, cancelNet := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-d.drainChan:
+			cancelNet()
+		case <-netCtx.Done():
+		}
+	}()
+	err := waitForNetwork(netCtx)
+	cancelNet()
+	if err != nil {
+		return d.shutdown()
+	}
 
 	ticker := time.NewTicker(time.Duration(d.config.Global.CheckInterval) * time.Second)
 	defer ticker.Stop()
 
+	// 監視配置文件，變更後立即重新加載；無法監視時退回每次檢查時比對修改時間
+	var configChanges <-chan struct{}
+	watcher, err := config.NewWatcher(d.config.WatchedFiles(), config.DefaultWatchDebounce)
+	if err != nil {
+		fmt.Printf("⚠️  無法監視配置文件，改為每次檢查時比對修改時間: %v\n", err)
+	} else {
+		defer watcher.Close()
+		configChanges = watcher.Changes()
+	}
+
 	fmt.Println("🚀 啟動 Cloudflare DDNS 服務...")
 	fmt.Printf("⏰ 檢查間隔: %d 秒\n", d.config.Global.CheckInterval)
 	fmt.Printf("📊 監控記錄數: %d\n", len(d.config.DNSRecords))
-	fmt.Printf("🌐 IP 檢查服務: %d 個\n", len(d.config.Global.IPCheckURLs))
+	for _, family := range d.activeFamilies() {
+		if d.config.Global.IPSource == config.IPSourceInterface {
+			fmt.Printf("🌐 %s 來源: 網絡介面 %s\n", family.label, d.config.Global.Interface.Name)
+		} else {
+			fmt.Printf("🌐 %s 檢查服務: %d 個\n", family.label, len(d.checkURLs(family)))
+		}
+	}
 	fmt.Printf("💾 暫存檔案: %s\n", d.cacheFile)
 
-	// 顯示暫存狀態
-	if d.currentIP != "" {
-		fmt.Printf("📁 載入暫存 IP: %s\n", d.currentIP)
-	} else {
-		fmt.Printf("📁 暫存 IP: 無\n")
-	}
+	for _, family := range d.activeFamilies() {
+		// 顯示暫存狀態
+		cachedIP := d.cachedIP(family)
+		if cachedIP != "" {
+			fmt.Printf("📁 載入暫存 %s: %s\n", family.label, cachedIP)
+		} else {
+			fmt.Printf("📁 暫存 %s: 無\n", family.label)
+		}
 
-	// 初始化當前 IP（如果暫存中沒有）
-	if d.currentIP == "" {
-		fmt.Println("\n🔍 初始 IP 檢查...")
-		initialIP, err := d.GetCurrentIP()
-		if err != nil {
-			fmt.Printf("❌ 初始 IP 獲取失敗: %v\n", err)
-			// 不立即退出，繼續嘗試
+		// 初始化當前 IP（如果暫存中沒有）
+		if cachedIP == "" {
+			fmt.Printf("\n🔍 初始 %s 檢查...\n", family.label)
+			initialIP, err := d.getCurrentIP(ctx, family)
+			if err != nil {
+				fmt.Printf("❌ 初始 %s 獲取失敗: %v\n", family.label, err)
+				// 不立即退出，繼續嘗試
+			} else {
+				d.setCachedIP(family, initialIP)
+				fmt.Printf("✅ 當前公共 %s: %s\n", family.label, initialIP)
+				d.saveIPCache()
+			}
 		} else {
-			d.currentIP = initialIP
-			fmt.Printf("✅ 當前公共 IP: %s\n", initialIP)
-			d.saveIPCache()
+			fmt.Printf("✅ 當前公共 %s: %s (從暫存)\n", family.label, cachedIP)
 		}
-	} else {
-		fmt.Printf("✅ 當前公共 IP: %s (從暫存)\n", d.currentIP)
 	}
 
 	// 立即執行一次檢查
 	fmt.Println("\n🔧 執行初始檢查...")
-	if err := d.UpdateDNSRecords(); err != nil {
+	if _, err := d.UpdateDNSRecords(ctx); err != nil {
 		fmt.Printf("❌ 初始檢查失敗: %v\n", err)
 	} else {
 		fmt.Printf("✅ 初始檢查完成\n")
@@ -554,49 +794,233 @@ func (d *DDNSService) Start() error {
 				fmt.Printf("\n[%s] ", time.Now().Format("15:04:05"))
 			}
 
-			// 檢查配置文件是否變更
-			if changed, err := d.config.HasChanged(); err == nil && changed {
-				fmt.Println("📁 檢測到配置文件變更，重新加載...")
-				if err := d.config.Reload(); err != nil {
-					fmt.Printf("❌ 重新加載配置文件失敗: %v\n", err)
-				} else {
-					// 只更新 Webhook 客戶端，不發送訊息
-					d.webhook = webhook.NewClient(
-						d.config.Webhook.URL,
-						d.config.Webhook.ChatID,
-						d.config.Webhook.Type,
-						d.config.Webhook.Template,
-						d.config.Webhook.Enabled,
-						d.config.Webhook.OnSuccess,
-						d.config.Webhook.OnFailure,
-					)
-					fmt.Printf("✅ 配置文件重新加載完成\n")
+			// 無法監視配置文件時，檢查配置文件是否變更
+			if watcher == nil {
+				if changed, err := d.config.HasChanged(); err == nil && changed {
+					fmt.Println("📁 檢測到配置文件變更，重新加載...")
+					d.reloadConfig(ticker)
 				}
 			}
 
 			// 執行 DNS 記錄更新檢查
-			if err := d.UpdateDNSRecords(); err != nil {
+			if _, err := d.UpdateDNSRecords(ctx); err != nil {
 				fmt.Printf("❌ 第 %d 次檢查失敗: %v\n", checkCounter, err)
 			}
 
-		case <-d.stopChan:
-			fmt.Println("\n🛑 收到停止信號，正在停止 DDNS 服務...")
-			d.webhook.SendInfo("DDNS 服務已停止")
-			return nil
+		case <-d.reloadChan:
+			checkCounter++
+			fmt.Println("\n🔄 收到重新加載請求，重新加載配置...")
+			d.reloadConfig(ticker)
+			d.updateWatchedFiles(watcher)
+
+			if _, err := d.UpdateDNSRecords(ctx); err != nil {
+				fmt.Printf("❌ 第 %d 次檢查失敗: %v\n", checkCounter, err)
+			}
+
+		case <-configChanges:
+			fmt.Printf("\n[%s] 📁 檢測到配置文件變更，重新加載...\n", time.Now().Format("15:04:05"))
+			added := d.reloadConfig(ticker)
+			d.updateWatchedFiles(watcher)
+
+			// 不會接著進行完整檢查，立即檢查新增的記錄
+			if len(added) > 0 {
+				d.checkAddedRecords(ctx, added)
+			}
+
+		case <-d.drainChan:
+			return d.shutdown()
+
+		case <-ctx.Done():
+			return d.shutdown()
+		}
+	}
+}
+
+// 重新加載配置文件：新配置驗證失敗時保留舊配置；
+// 成功後替換 Cloudflare 客戶端、調整檢查間隔，回傳新增的記錄
+func (d *DDNSService) reloadConfig(ticker *time.Ticker) []config.DNSRecord {
+	newConfig, err := config.LoadConfig(d.config.ConfigPath)
+	if err == nil {
+		err = newConfig.Validate()
+	}
+	if err != nil {
+		fmt.Printf("❌ 重新加載配置文件失敗，繼續使用舊配置: %v\n", err)
+		d.skipConfigChange()
+		return nil
+	}
+
+	cfClient, err := cloudflare.NewClient(&newConfig.Cloudflare)
+	if err != nil {
+		fmt.Printf("❌ 創建 Cloudflare 客戶端失敗，繼續使用舊配置: %v\n", err)
+		d.skipConfigChange()
+		return nil
+	}
+
+	added, removed := diffRecords(d.config.DNSRecords, newConfig.DNSRecords)
+	intervalChanged := newConfig.Global.CheckInterval != d.config.Global.CheckInterval
+
+	*d.config = *newConfig
+	d.cfClient = cfClient
+	d.webhook = webhook.NewClient(
+		d.config.Webhook.URL,
+		d.config.Webhook.ChatID,
+		d.config.Webhook.Type,
+		d.config.Webhook.Template,
+		d.config.Webhook.Enabled,
+		d.config.Webhook.OnSuccess,
+		d.config.Webhook.OnFailure,
+	)
+
+	if intervalChanged {
+		interval := time.Duration(d.config.Global.CheckInterval) * time.Second
+		ticker.Reset(interval)
+		d.nextCheck = time.Now().Add(interval)
+		fmt.Printf("⏰ 檢查間隔變更為: %d 秒\n", d.config.Global.CheckInterval)
+	}
+
+	// 記錄的 proxied / ttl 可能已變更，下次檢查時實際檢查所有記錄
+	d.lastVerify = time.Time{}
+
+	// 移除已刪除記錄的暫存
+	for _, key := range removed {
+		d.forgetRecord(key)
+	}
+	if len(removed) > 0 {
+		fmt.Printf("🗑️  移除 %d 個記錄的暫存\n", len(removed))
+		d.saveIPCache()
+	}
+
+	fmt.Printf("✅ 配置文件重新加載完成\n")
+	return added
+}
+
+// 重新加載後配置引用的檔案可能改變，更新監視列錶
+func (d *DDNSService) updateWatchedFiles(watcher *config.Watcher) {
+	if watcher == nil {
+		return
+	}
+	if err := watcher.SetFiles(d.config.WatchedFiles()); err != nil {
+		fmt.Printf("⚠️  更新配置文件監視失敗: %v\n", err)
+	}
+}
+
+// 記錄當前配置文件的修改時間，無效的配置文件再次修改前不會重複加載
+func (d *DDNSService) skipConfigChange() {
+	if info, err := os.Stat(d.config.ConfigPath); err == nil {
+		d.config.LastModified = info.ModTime()
+	}
+}
+
+// 比較新舊配置的記錄，回傳新增的記錄和已刪除記錄的暫存鍵
+func diffRecords(oldRecords, newRecords []config.DNSRecord) ([]config.DNSRecord, []string) {
+	oldKeys := make(map[string]bool)
+	for _, record := range oldRecords {
+		oldKeys[recordKey(&record)] = true
+	}
+
+	var added []config.DNSRecord
+	newKeys := make(map[string]bool)
+	for _, record := range newRecords {
+		key := recordKey(&record)
+		newKeys[key] = true
+		if !oldKeys[key] {
+			added = append(added, record)
 		}
 	}
+
+	var removed []string
+	for key := range oldKeys {
+		if !newKeys[key] {
+			removed = append(removed, key)
+		}
+	}
+
+	return added, removed
+}
+
+// 立即檢查新增的記錄，不等待下次檢查
+func (d *DDNSService) checkAddedRecords(ctx context.Context, records []config.DNSRecord) {
+	ctx, cancel := context.WithTimeout(ctx, d.cycleTimeout())
+	defer cancel()
+
+	fmt.Printf("🆕 檢查 %d 個新增的記錄...\n", len(records))
+
+	if d.dryRun {
+		printPlan(d.planRecords(ctx, records))
+		return
+	}
+
+	for _, family := range d.activeFamilies() {
+		var familyRecords []config.DNSRecord
+		for _, record := range records {
+			if strings.EqualFold(record.Type, family.recordType) {
+				familyRecords = append(familyRecords, record)
+			}
+		}
+		if len(familyRecords) == 0 {
+			continue
+		}
+
+		currentIP := d.cachedIP(family)
+		if currentIP == "" {
+			ip, err := d.getCurrentIP(ctx, family)
+			if err != nil {
+				fmt.Printf("❌ 獲取當前 %s 失敗: %v\n", family.label, err)
+				continue
+			}
+			currentIP = ip
+			d.setCachedIP(family, currentIP)
+		}
+
+		d.verifyDNSRecordsSync(ctx, familyRecords, currentIP)
+	}
+
+	d.saveIPCache()
+}
+
+// 發送停止通知；原本的 context 已取消，改用獨立的超時
+func (d *DDNSService) shutdown() error {
+	fmt.Println("\n🛑 收到停止信號，正在停止 DDNS 服務...")
+	if d.dryRun {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	d.webhook.SendInfo(ctx, "DDNS 服務已停止")
+	return nil
+}
+
+// 請求重新加載配置並立即執行一次檢查
+func (d *DDNSService) Reload() {
+	select {
+	case d.reloadChan <- struct{}{}:
+	default:
+		// 已有待處理的重新加載請求
+	}
+}
+
+// 完成當前檢查後停止服務，可重複調用
+func (d *DDNSService) Shutdown() {
+	d.drainOnce.Do(func() {
+		close(d.drainChan)
+	})
 }
 
+// 停止服務並中止進行中的請求，可重複調用
 func (d *DDNSService) Stop() {
-	fmt.Println("\n⏹️  正在停止服務...")
-	d.stopChan <- true
+	d.stopOnce.Do(func() {
+		fmt.Println("\n⏹️  正在停止服務...")
+		close(d.stopChan)
+	})
 }
 
 // 獲取服務狀態信息
 func (d *DDNSService) GetStatus() map[string]any {
 	status := make(map[string]any)
 	status["current_ip"] = d.currentIP
-	status["dns_records"] = d.dnsIPs
+	status["current_ipv6"] = d.currentIPv6
+	status["dns_records"] = d.dnsIPsSnapshot()
 	status["last_check"] = d.lastCheck.Format("2006-01-02 15:04:05")
 	status["next_check"] = d.nextCheck.Format("2006-01-02 15:04:05")
 	status["monitored_records"] = len(d.config.DNSRecords)
@@ -611,13 +1035,14 @@ func (d *DDNSService) GetStatus() map[string]any {
 }
 
 // 手動觸發立即檢查
-func (d *DDNSService) ForceUpdate() error {
+func (d *DDNSService) ForceUpdate(ctx context.Context) error {
 	fmt.Println("🔧 手動觸發立即檢查...")
-	return d.UpdateDNSRecords()
+	_, err := d.UpdateDNSRecords(ctx)
+	return err
 }
 
 // 檢查特定記錄的狀態
-func (d *DDNSService) CheckRecordStatus(recordName string) (map[string]string, error) {
+func (d *DDNSService) CheckRecordStatus(ctx context.Context, recordName string) (map[string]string, error) {
 	result := make(map[string]string)
 
 	// 查找記錄配置
@@ -633,22 +1058,23 @@ func (d *DDNSService) CheckRecordStatus(recordName string) (map[string]string, e
 		return nil, fmt.Errorf("未找到記錄: %s", recordName)
 	}
 
-	// 獲取當前公共 IP
-	currentIP, err := d.GetCurrentIP()
+	// 獲取記錄類型對應的當前公共 IP
+	currentIP, err := d.GetCurrentIPForType(ctx, recordConfig.Type)
 	if err != nil {
 		return nil, err
 	}
 	result["current_ip"] = currentIP
 
 	// 獲取 DNS 記錄 IP
-	dnsIP, err := d.cfClient.GetDNSRecordIP(recordName, recordConfig.Type)
+	cfRecord, err := d.cfClient.GetDNSRecord(ctx, recordConfig)
 	if err != nil {
 		return nil, err
 	}
+	dnsIP := cfRecord.Content
 	result["dns_ip"] = dnsIP
 
 	// 檢查同步狀態
-	if currentIP == dnsIP {
+	if SameIP(currentIP, dnsIP) {
 		result["status"] = "同步"
 		result["sync_status"] = "✅"
 	} else {
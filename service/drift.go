@@ -0,0 +1,78 @@
+package service
+
+import (
+	"cfddns/cloudflare"
+	"cfddns/config"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 比較 Cloudflare 中的設定與配置，回傳 proxied / ttl 的差異描述
+func recordDrift(record *config.DNSRecord, proxied bool, ttl int) []string {
+	var diffs []string
+	if proxied != record.Proxied {
+		diffs = append(diffs, fmt.Sprintf("proxied %v → %v", proxied, record.Proxied))
+	}
+	if want := cloudflare.EffectiveTTL(record); ttl != want {
+		diffs = append(diffs, fmt.Sprintf("TTL %s → %s", formatPlanTTL(ttl), formatPlanTTL(want)))
+	}
+	return diffs
+}
+
+// 是否需要將 proxied / ttl 恢復為配置的值
+func enforceDrift(record *config.DNSRecord) bool {
+	return record.Drift == "" || record.Drift == config.DriftEnforce
+}
+
+// 計算需要修改的欄位：IP 不同時修改內容，drift=enforce 時修正 proxied / ttl，
+// 啟用擁有者標記時加上標記。同時回傳設定偏移的描述，供 drift=warn 顯示
+func (d *DDNSService) recordPatch(record *config.DNSRecord, current CachedRecord, newIP string) (cloudflare.RecordPatch, []string, error) {
+	var patch cloudflare.RecordPatch
+	if !SameIP(current.Content, newIP) {
+		patch.Content = &newIP
+	}
+
+	drift := recordDrift(record, current.Proxied, current.TTL)
+	if enforceDrift(record) && len(drift) > 0 {
+		if current.Proxied != record.Proxied {
+			patch.Proxied = &record.Proxied
+		}
+		if ttl := cloudflare.EffectiveTTL(record); current.TTL != ttl {
+			patch.TTL = &ttl
+		}
+	}
+
+	err := d.stampOwner(record, current, &patch)
+	return patch, drift, err
+}
+
+// 內容以外的修改描述，例如 "proxied false → true, TTL 300 → 自動"
+func describeChanges(current CachedRecord, patch cloudflare.RecordPatch) string {
+	var changes []string
+	if patch.Proxied != nil {
+		changes = append(changes, fmt.Sprintf("proxied %v → %v", current.Proxied, *patch.Proxied))
+	}
+	if patch.TTL != nil {
+		changes = append(changes, fmt.Sprintf("TTL %s → %s", formatPlanTTL(current.TTL), formatPlanTTL(*patch.TTL)))
+	}
+	if stampsOwner(patch) {
+		changes = append(changes, "加上擁有者標記")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// drift=warn 時顯示設定偏移的警告
+func warnDrift(out io.Writer, record *config.DNSRecord, drift []string) {
+	if record.Drift == config.DriftWarn && len(drift) > 0 {
+		fmt.Fprintf(out, "⚠️  記錄 %s 的設定與配置不同 (%s)，drift=warn 不修正\n",
+			record.Name, strings.Join(drift, ", "))
+	}
+}
+
+// 是否需要忽略暫存，實際檢查 Cloudflare 中的記錄
+func (d *DDNSService) shouldVerify() bool {
+	interval := time.Duration(d.config.Global.VerifyInterval) * time.Second
+	return d.lastVerify.IsZero() || time.Since(d.lastVerify) >= interval
+}
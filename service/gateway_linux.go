@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 路由表中表示經過閘道的旗標 (RTF_GATEWAY)
+const rtfGateway = 0x2
+
+// 從路由表 (/proc/net/route) 取得 IPv4 預設閘道
+func defaultGateway() (netip.Addr, error) {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("讀取路由表失敗: %w", err)
+	}
+	defer file.Close()
+
+	// 每行格式: 介面 目的地 閘道 旗標 ...（地址為小端序十六進制）
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[1] != "00000000" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&rtfGateway == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		var addr [4]byte
+		binary.LittleEndian.PutUint32(addr[:], uint32(value))
+		return netip.AddrFrom4(addr), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return netip.Addr{}, fmt.Errorf("讀取路由表失敗: %w", err)
+	}
+
+	return netip.Addr{}, errors.New("找不到預設閘道")
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package service
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// 其他平台無法讀取路由表，NAT-PMP 和 PCP 無法使用（UPnP 以組播發現路由器，不受影響）
+func defaultGateway() (netip.Addr, error) {
+	return netip.Addr{}, errors.New("此平台不支援取得預設閘道")
+}
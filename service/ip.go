@@ -0,0 +1,258 @@
+package service
+
+import (
+	"cfddns/config"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IP 協議族
+type ipFamily struct {
+	recordType string // 對應的 DNS 記錄類型
+	network    string // 撥號使用的網絡類型
+	packet     string // UDP 撥號使用的網絡類型
+	label      string // 顯示名稱
+}
+
+var (
+	familyIPv4 = ipFamily{recordType: "A", network: "tcp4", packet: "udp4", label: "IPv4"}
+	familyIPv6 = ipFamily{recordType: "AAAA", network: "tcp6", packet: "udp6", label: "IPv6"}
+)
+
+// 根據記錄類型取得對應的協議族
+func familyOf(recordType string) (ipFamily, bool) {
+	switch strings.ToUpper(recordType) {
+	case "A":
+		return familyIPv4, true
+	case "AAAA":
+		return familyIPv6, true
+	}
+	return ipFamily{}, false
+}
+
+// 獲取當前公共 IPv4
+func (d *DDNSService) GetCurrentIP(ctx context.Context) (string, error) {
+	return d.getCurrentIP(ctx, familyIPv4)
+}
+
+// 獲取當前公共 IPv6
+func (d *DDNSService) GetCurrentIPv6(ctx context.Context) (string, error) {
+	return d.getCurrentIP(ctx, familyIPv6)
+}
+
+// 根據記錄類型獲取對應的公共 IP
+func (d *DDNSService) GetCurrentIPForType(ctx context.Context, recordType string) (string, error) {
+	family, ok := familyOf(recordType)
+	if !ok {
+		return "", fmt.Errorf("不支援的記錄類型: %s", recordType)
+	}
+	return d.getCurrentIP(ctx, family)
+}
+
+// 公共 IP 來源
+type ipProvider interface {
+	// 獲取指定協議族的 IP（回傳標準格式）
+	GetIP(ctx context.Context, family ipFamily) (string, error)
+	// 顯示名稱
+	String() string
+}
+
+// 取得協議族對應的 IP 檢查服務
+func (d *DDNSService) checkURLs(family ipFamily) []string {
+	if family == familyIPv6 {
+		return d.config.Global.IPv6CheckURLs
+	}
+	return d.config.Global.IPCheckURLs
+}
+
+// 取得協議族對應的 IP 來源，依序嘗試
+func (d *DDNSService) ipProviders(family ipFamily) []ipProvider {
+	if d.config.Global.IPSource == config.IPSourceInterface {
+		return []ipProvider{interfaceProvider{config: d.config.Global.Interface}}
+	}
+
+	var providers []ipProvider
+	for _, entry := range d.checkURLs(family) {
+		if name, ok := strings.CutPrefix(entry, config.DNSCheckPrefix); ok {
+			providers = append(providers, dnsProvider{name: name})
+			continue
+		}
+		if server, ok := strings.CutPrefix(entry, config.STUNCheckPrefix); ok {
+			providers = append(providers, stunProvider{server: server})
+			continue
+		}
+		if protocols, ok := config.RouterCheckProtocols(entry); ok {
+			providers = append(providers, routerProvider{entry: entry, protocols: protocols})
+			continue
+		}
+		providers = append(providers, httpProvider{url: entry})
+	}
+	return providers
+}
+
+// 獲取當前公共 IP；dry-run 模式下不發送通知
+func (d *DDNSService) getCurrentIP(ctx context.Context, family ipFamily) (string, error) {
+	return d.lookupIP(ctx, family, !d.dryRun)
+}
+
+// 依序查詢 IP 來源，notify 為 false 時不發送 Webhook 通知（plan 等唯讀操作）
+func (d *DDNSService) lookupIP(ctx context.Context, family ipFamily, notify bool) (string, error) {
+	var lastErr error
+
+	if verbose {
+		fmt.Printf("🔍 正在檢查公共 %s...\n", family.label)
+	}
+
+	providers := d.ipProviders(family)
+	if len(providers) == 0 {
+		return "", fmt.Errorf("未配置 %s 檢查服務", family.label)
+	}
+	if d.config.Global.Consensus.Enabled && len(providers) > 1 {
+		return d.consensusIP(ctx, family, providers, notify)
+	}
+
+	for i, provider := range providers {
+		if verbose {
+			fmt.Printf("   嘗試服務 %d: %s\n", i+1, provider)
+		}
+
+		ip, err := provider.GetIP(ctx, family)
+		if err == nil {
+			if verbose {
+				fmt.Printf("   ✅ 從 %s 獲取到有效 IP: %s\n", provider, ip)
+			}
+			return ip, nil
+		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s 檢查已取消: %w", family.label, ctx.Err())
+		}
+
+		lastErr = err
+		if verbose {
+			fmt.Printf("   ❌ %v\n", lastErr)
+		}
+	}
+
+	return "", fmt.Errorf("所有 %s 檢查服務都失敗: %w", family.label, lastErr)
+}
+
+// 以 HTTP 查詢 IP 檢查服務，響應內容為純文字 IP
+type httpProvider struct {
+	url string
+}
+
+func (p httpProvider) String() string {
+	return p.url
+}
+
+func (p httpProvider) GetIP(ctx context.Context, family ipFamily) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("服務 %s 無效: %w", p.url, err)
+	}
+
+	resp, err := newIPCheckClient(family).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("服務 %s 失敗: %w", p.url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("讀取響應失敗: %w", err)
+	}
+
+	ip := strings.TrimSpace(string(body))
+	normalized, ok := normalizeIP(ip, family)
+	if !ok {
+		return "", fmt.Errorf("從 %s 獲取的 IP 無效: %s", p.url, ip)
+	}
+	return normalized, nil
+}
+
+// 建立只使用指定協議族連線的 HTTP 客戶端，
+// 避免雙棧環境下 IPv6 檢查服務回傳 IPv4 地址（或相反）
+func newIPCheckClient(family ipFamily) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, family.network, addr)
+	}
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// 驗證 IP 是否屬於指定協議族，並回傳標準格式
+func normalizeIP(ip string, family ipFamily) (string, bool) {
+	if family == familyIPv4 {
+		return ip, isValidIP(ip)
+	}
+	return normalizeIPv6(ip)
+}
+
+// 驗證 IPv6 地址並轉換為標準的壓縮格式
+func normalizeIPv6(ip string) (string, bool) {
+	if !strings.Contains(ip, ":") {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || !addr.Is6() || addr.Is4In6() || addr.Zone() != "" {
+		return "", false
+	}
+	return addr.String(), true
+}
+
+// 比較兩個 IP 是否相同（IPv6 可能有不同的書寫格式）
+func SameIP(a, b string) bool {
+	if a == b {
+		return true
+	}
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	return errA == nil && errB == nil && addrA == addrB
+}
+
+// 檢查 IP 地址是否有效
+func isValidIP(ip string) bool {
+	if ip == "" {
+		return false
+	}
+
+	// 簡單的 IPv4 驗證
+	parts := strings.Split(ip, ".")
+	if len(parts) != 4 {
+		return false
+	}
+
+	for _, part := range parts {
+		if len(part) == 0 || len(part) > 3 {
+			return false
+		}
+
+		// 檢查是否為數字
+		for _, char := range part {
+			if char < '0' || char > '9' {
+				return false
+			}
+		}
+
+		// 檢查數字範圍
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 0 || num > 255 {
+			return false
+		}
+
+		// 檢查前導零（但允許 "0"）
+		if len(part) > 1 && part[0] == '0' {
+			return false
+		}
+	}
+
+	return true
+}
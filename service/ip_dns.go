@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNS 查詢的超時時間
+const dnsQueryTimeout = 5 * time.Second
+
+// 以 DNS 查詢公共 IP 的服務：向指定的伺服器查詢特殊名稱，回應中即為查詢者的地址
+type dnsIPService struct {
+	question dnsmessage.Name
+	class    dnsmessage.Class
+	txt      bool   // 以 TXT 記錄回傳地址，否則查詢 A / AAAA
+	server4  string // IPv4 伺服器
+	server6  string // IPv6 伺服器
+}
+
+var dnsIPServices = map[string]dnsIPService{
+	"opendns": {
+		question: dnsmessage.MustNewName("myip.opendns.com."),
+		class:    dnsmessage.ClassINET,
+		server4:  "208.67.222.222:53", // resolver1.opendns.com
+		server6:  "[2620:119:35::35]:53",
+	},
+	"cloudflare": {
+		question: dnsmessage.MustNewName("whoami.cloudflare."),
+		class:    dnsmessage.ClassCHAOS,
+		txt:      true,
+		server4:  "1.1.1.1:53",
+		server6:  "[2606:4700:4700::1111]:53",
+	},
+	"google": {
+		question: dnsmessage.MustNewName("o-o.myaddr.l.google.com."),
+		class:    dnsmessage.ClassINET,
+		txt:      true,
+		server4:  "216.239.32.10:53", // ns1.google.com
+		server6:  "[2001:4860:4802:32::a]:53",
+	},
+}
+
+// 以 DNS 查詢公共 IP，不經過 HTTP 代理
+type dnsProvider struct {
+	name string
+}
+
+func (p dnsProvider) String() string {
+	return "dns:" + p.name
+}
+
+func (p dnsProvider) GetIP(ctx context.Context, family ipFamily) (string, error) {
+	service, ok := dnsIPServices[p.name]
+	if !ok {
+		return "", fmt.Errorf("不支援的 DNS 服務: %s", p.name)
+	}
+
+	server := service.server4
+	qtype := dnsmessage.TypeA
+	if family == familyIPv6 {
+		server = service.server6
+		qtype = dnsmessage.TypeAAAA
+	}
+	if service.txt {
+		qtype = dnsmessage.TypeTXT
+	}
+
+	answers, err := queryDNS(ctx, family, server, dnsmessage.Question{
+		Name:  service.question,
+		Type:  qtype,
+		Class: service.class,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s 查詢失敗: %w", p, err)
+	}
+
+	for _, answer := range answers {
+		if ip, ok := normalizeIP(answer, family); ok {
+			return ip, nil
+		}
+	}
+	return "", fmt.Errorf("%s 的回應中沒有有效的 %s", p, family.label)
+}
+
+// 以 UDP 發送 DNS 查詢，回傳回答中的地址或 TXT 內容
+func queryDNS(ctx context.Context, family ipFamily, server string, question dnsmessage.Question) ([]string, error) {
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{question},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("建立查詢失敗: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, family.packet, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		answers, err := parseDNSAnswers(buf[:n], id)
+		if errors.Is(err, errDNSIDMismatch) {
+			// 不是這次查詢的回應（例如之前逾時的回應），繼續等待
+			continue
+		}
+		return answers, err
+	}
+}
+
+var errDNSIDMismatch = errors.New("DNS 回應 ID 不符")
+
+// 解析 DNS 回應中的 A / AAAA / TXT 回答
+func parseDNSAnswers(msg []byte, id uint16) ([]string, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(msg)
+	if err != nil {
+		return nil, fmt.Errorf("解析回應失敗: %w", err)
+	}
+	if header.ID != id || !header.Response {
+		return nil, errDNSIDMismatch
+	}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("伺服器回應錯誤: %s", header.RCode)
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return nil, fmt.Errorf("解析回應失敗: %w", err)
+	}
+
+	var answers []string
+	for {
+		answer, err := parser.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析回應失敗: %w", err)
+		}
+
+		switch answer.Type {
+		case dnsmessage.TypeA:
+			r, err := parser.AResource()
+			if err != nil {
+				return nil, fmt.Errorf("解析回應失敗: %w", err)
+			}
+			answers = append(answers, net.IP(r.A[:]).String())
+		case dnsmessage.TypeAAAA:
+			r, err := parser.AAAAResource()
+			if err != nil {
+				return nil, fmt.Errorf("解析回應失敗: %w", err)
+			}
+			answers = append(answers, net.IP(r.AAAA[:]).String())
+		case dnsmessage.TypeTXT:
+			r, err := parser.TXTResource()
+			if err != nil {
+				return nil, fmt.Errorf("解析回應失敗: %w", err)
+			}
+			answers = append(answers, r.TXT...)
+		default:
+			if err := parser.SkipAnswer(); err != nil {
+				return nil, fmt.Errorf("解析回應失敗: %w", err)
+			}
+		}
+	}
+
+	return answers, nil
+}
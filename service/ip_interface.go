@@ -0,0 +1,112 @@
+package service
+
+import (
+	"cfddns/config"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// IPv6 地址旗標（與 Linux 的 IFA_F_* 相同）
+const (
+	ifaFlagTemporary  = 0x01 // 臨時地址（隱私擴展）
+	ifaFlagDADFailed  = 0x08 // 重複地址檢測失敗
+	ifaFlagDeprecated = 0x20 // 已棄用
+	ifaFlagTentative  = 0x40 // 重複地址檢測尚未完成
+)
+
+// 電信級 NAT 使用的共享地址空間 (RFC 6598)
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// 從本機網絡介面讀取地址，適用於直接持有公共地址的主機（PPPoE、VPS、IPv6 SLAAC）
+type interfaceProvider struct {
+	config config.InterfaceConfig
+}
+
+func (p interfaceProvider) String() string {
+	return "網絡介面 " + p.config.Name
+}
+
+func (p interfaceProvider) GetIP(_ context.Context, family ipFamily) (string, error) {
+	iface, err := net.InterfaceByName(p.config.Name)
+	if err != nil {
+		return "", fmt.Errorf("找不到網絡介面 %s: %w", p.config.Name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("讀取網絡介面 %s 的地址失敗: %w", p.config.Name, err)
+	}
+
+	var prefix netip.Prefix
+	if p.config.CIDR != "" {
+		if prefix, err = netip.ParsePrefix(p.config.CIDR); err != nil {
+			return "", fmt.Errorf("介面網段無效: %w", err)
+		}
+		if prefix.Addr().Is4() != (family == familyIPv4) {
+			// 網段只限制相同協議族的地址，雙棧時另一協議族不受影響
+			prefix = netip.Prefix{}
+		}
+	}
+
+	var flags map[netip.Addr]uint32
+	if family == familyIPv6 {
+		if flags, err = ipv6AddrFlags(p.config.Name); err != nil && verbose {
+			fmt.Printf("   ⚠️  無法讀取 IPv6 地址旗標，不過濾臨時和已棄用的地址: %v\n", err)
+		}
+	}
+
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok {
+			continue
+		}
+		addr = addr.Unmap()
+		if (family == familyIPv4) != addr.Is4() {
+			continue
+		}
+
+		if reason := p.reject(addr, flags[addr]); reason != "" {
+			if verbose {
+				fmt.Printf("   略過 %s: %s\n", addr, reason)
+			}
+			continue
+		}
+		if prefix.IsValid() && !prefix.Contains(addr) {
+			if verbose {
+				fmt.Printf("   略過 %s: 不在網段 %s 內\n", addr, prefix)
+			}
+			continue
+		}
+
+		return addr.String(), nil
+	}
+
+	return "", fmt.Errorf("網絡介面 %s 沒有符合條件的 %s 地址", p.config.Name, family.label)
+}
+
+// 地址不可使用的原因，可以使用時回傳空字串
+func (p interfaceProvider) reject(addr netip.Addr, flags uint32) string {
+	switch {
+	case flags&(ifaFlagTentative|ifaFlagDADFailed) != 0:
+		return "重複地址檢測未通過"
+	case flags&ifaFlagTemporary != 0 && !p.config.Temporary:
+		return "臨時地址"
+	case flags&ifaFlagDeprecated != 0 && !p.config.Deprecated:
+		return "已棄用的地址"
+	case !addr.IsGlobalUnicast():
+		return "鏈路本地或回環地址"
+	case p.config.Scope == config.ScopeGlobal && !isPublicAddr(addr):
+		return "非公共地址"
+	}
+	return ""
+}
+
+// 是否為公共地址（排除 0.0.0.0、鏈路本地、私有和共享地址等）
+func isPublicAddr(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 讀取網絡介面 IPv6 地址的旗標 (/proc/net/if_inet6)
+func ipv6AddrFlags(name string) (map[netip.Addr]uint32, error) {
+	file, err := os.Open("/proc/net/if_inet6")
+	if err != nil {
+		return nil, fmt.Errorf("讀取 IPv6 地址列錶失敗: %w", err)
+	}
+	defer file.Close()
+
+	// 每行格式: 地址 介面索引 前綴長度 範圍 旗標 介面名稱（數值皆為十六進制）
+	flags := make(map[netip.Addr]uint32)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[5] != name {
+			continue
+		}
+
+		raw, err := hex.DecodeString(fields[0])
+		if err != nil || len(raw) != 16 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			continue
+		}
+		flags[netip.AddrFrom16([16]byte(raw))] = uint32(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("讀取 IPv6 地址列錶失敗: %w", err)
+	}
+
+	return flags, nil
+}
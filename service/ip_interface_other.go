@@ -0,0 +1,10 @@
+//go:build !linux
+
+package service
+
+import "net/netip"
+
+// 其他平台無法讀取 IPv6 地址旗標，不過濾臨時和已棄用的地址
+func ipv6AddrFlags(string) (map[netip.Addr]uint32, error) {
+	return nil, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// 向家用路由器查詢 WAN 地址，依序嘗試 UPnP IGD、NAT-PMP 和 PCP
+type routerProvider struct {
+	entry     string
+	protocols []string
+}
+
+func (p routerProvider) String() string {
+	return p.entry
+}
+
+func (p routerProvider) GetIP(ctx context.Context, family ipFamily) (string, error) {
+	if family != familyIPv4 {
+		return "", fmt.Errorf("%s 僅支援 IPv4", p)
+	}
+
+	var errs []string
+	for _, protocol := range p.protocols {
+		addr, err := routerExternalIP(ctx, protocol)
+		switch {
+		case err != nil:
+		case !addr.IsGlobalUnicast():
+			// 部分路由器在 WAN 連線中斷時回傳 0.0.0.0
+			err = fmt.Errorf("WAN 地址 %s 無效（WAN 連線可能已中斷）", addr)
+		case !isPublicAddr(addr):
+			err = fmt.Errorf("WAN 地址 %s 不是公共地址（路由器可能位於另一層 NAT 之後）", addr)
+		}
+		if err == nil {
+			return addr.String(), nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		if verbose {
+			fmt.Printf("   ⚠️  %s: %v\n", protocol, err)
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", protocol, err))
+	}
+
+	return "", fmt.Errorf("%s 查詢失敗 (%s)", p, strings.Join(errs, "; "))
+}
+
+// 以指定協議向路由器查詢 WAN 地址
+func routerExternalIP(ctx context.Context, protocol string) (netip.Addr, error) {
+	if protocol == "upnp" {
+		return upnpExternalIP(ctx)
+	}
+
+	gateway, err := defaultGateway()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	switch protocol {
+	case "natpmp":
+		return natpmpExternalIP(ctx, netip.AddrPortFrom(gateway, natpmpPort))
+	case "pcp":
+		return pcpExternalIP(ctx, netip.AddrPortFrom(gateway, natpmpPort))
+	}
+	return netip.Addr{}, errors.New("不支援的協議")
+}
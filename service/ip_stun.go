@@ -0,0 +1,194 @@
+package service
+
+import (
+	"cfddns/config"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// STUN (RFC 5389) 訊息常數
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+	stunBindingError    = 0x0111
+	stunMagicCookie     = 0x2112A442
+	stunHeaderSize      = 20
+
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXORMappedAddress = 0x0020
+)
+
+const (
+	stunAttempts       = 3                       // 請求次數（UDP 可能遺失）
+	stunAttemptTimeout = 1500 * time.Millisecond // 每次請求等待回應的時間
+)
+
+var errSTUNMismatch = errors.New("STUN 回應的交易 ID 不符")
+
+// 以 STUN 綁定請求取得 NAT 映射後的公共地址，不經過 HTTP 代理
+type stunProvider struct {
+	server string
+}
+
+func (p stunProvider) String() string {
+	return "stun:" + p.server
+}
+
+func (p stunProvider) GetIP(ctx context.Context, family ipFamily) (string, error) {
+	addr, err := config.STUNServerAddr(p.server)
+	if err != nil {
+		return "", err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, family.packet, addr)
+	if err != nil {
+		return "", fmt.Errorf("%s 連線失敗: %w", p, err)
+	}
+	defer conn.Close()
+
+	var transactionID [12]byte
+	rand.Read(transactionID[:])
+	request := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(request[0:], stunBindingRequest)
+	binary.BigEndian.PutUint16(request[2:], 0)
+	binary.BigEndian.PutUint32(request[4:], stunMagicCookie)
+	copy(request[8:], transactionID[:])
+
+	buf := make([]byte, 1500)
+	for attempt := 1; attempt <= stunAttempts; attempt++ {
+		deadline := time.Now().Add(stunAttemptTimeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		conn.SetDeadline(deadline)
+
+		if _, err := conn.Write(request); err != nil {
+			return "", fmt.Errorf("%s 發送請求失敗: %w", p, err)
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return "", ctx.Err()
+				}
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() && attempt < stunAttempts {
+					break // 重新發送請求
+				}
+				return "", fmt.Errorf("%s 沒有回應: %w", p, err)
+			}
+
+			mapped, err := parseSTUNResponse(buf[:n], transactionID)
+			if errors.Is(err, errSTUNMismatch) {
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("%s %w", p, err)
+			}
+
+			ip, ok := normalizeIP(mapped.String(), family)
+			if !ok {
+				return "", fmt.Errorf("%s 回傳的地址不是 %s: %s", p, family.label, mapped)
+			}
+			return ip, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s 沒有回應", p)
+}
+
+// 解析 STUN 綁定回應中的映射地址，優先使用 XOR-MAPPED-ADDRESS
+func parseSTUNResponse(msg []byte, transactionID [12]byte) (netip.Addr, error) {
+	if len(msg) < stunHeaderSize || binary.BigEndian.Uint32(msg[4:]) != stunMagicCookie {
+		return netip.Addr{}, errSTUNMismatch
+	}
+	if [12]byte(msg[8:20]) != transactionID {
+		return netip.Addr{}, errSTUNMismatch
+	}
+
+	switch binary.BigEndian.Uint16(msg[0:]) {
+	case stunBindingResponse:
+	case stunBindingError:
+		return netip.Addr{}, errors.New("回應綁定錯誤")
+	default:
+		return netip.Addr{}, errSTUNMismatch
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:]))
+	if length%4 != 0 || stunHeaderSize+length > len(msg) {
+		// 屬性以 4 位元組對齊，訊息長度必為 4 的倍數
+		return netip.Addr{}, errors.New("回應長度無效")
+	}
+	attrs := msg[stunHeaderSize : stunHeaderSize+length]
+
+	var mapped netip.Addr
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:]))
+		if 4+attrLen > len(attrs) {
+			return netip.Addr{}, errors.New("回應屬性長度無效")
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunAttrXORMappedAddress:
+			if addr, ok := parseSTUNAddress(value, true, transactionID); ok {
+				return addr, nil
+			}
+		case stunAttrMappedAddress:
+			if addr, ok := parseSTUNAddress(value, false, transactionID); ok {
+				mapped = addr
+			}
+		}
+
+		// 屬性以 4 位元組對齊
+		attrs = attrs[4+(attrLen+3)&^3:]
+	}
+
+	if !mapped.IsValid() {
+		return netip.Addr{}, errors.New("回應中沒有映射地址")
+	}
+	return mapped, nil
+}
+
+// 解析 (XOR-)MAPPED-ADDRESS 屬性：保留(1) 地址族(1) 埠(2) 地址(4 或 16)
+func parseSTUNAddress(value []byte, xor bool, transactionID [12]byte) (netip.Addr, bool) {
+	if len(value) < 4 {
+		return netip.Addr{}, false
+	}
+
+	var key [16]byte
+	binary.BigEndian.PutUint32(key[0:], stunMagicCookie)
+	copy(key[4:], transactionID[:])
+
+	ip := value[4:]
+	switch value[1] {
+	case 0x01:
+		if len(ip) != 4 {
+			return netip.Addr{}, false
+		}
+	case 0x02:
+		if len(ip) != 16 {
+			return netip.Addr{}, false
+		}
+	default:
+		return netip.Addr{}, false
+	}
+
+	raw := make([]byte, len(ip))
+	for i := range ip {
+		raw[i] = ip[i]
+		if xor {
+			raw[i] ^= key[i]
+		}
+	}
+	return netip.AddrFromSlice(raw)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+var testTransactionID = [12]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+// 組成 STUN 訊息，attrs 為已編碼的屬性
+func stunMessage(msgType uint16, length int, transactionID [12]byte, attrs []byte) []byte {
+	msg := make([]byte, stunHeaderSize, stunHeaderSize+len(attrs))
+	binary.BigEndian.PutUint16(msg[0:], msgType)
+	binary.BigEndian.PutUint16(msg[2:], uint16(length))
+	binary.BigEndian.PutUint32(msg[4:], stunMagicCookie)
+	copy(msg[8:], transactionID[:])
+	return append(msg, attrs...)
+}
+
+// 編碼單一屬性並補齊至 4 位元組
+func stunAttr(attrType uint16, value []byte) []byte {
+	attr := make([]byte, 4, 4+len(value)+3)
+	binary.BigEndian.PutUint16(attr[0:], attrType)
+	binary.BigEndian.PutUint16(attr[2:], uint16(len(value)))
+	attr = append(attr, value...)
+	for len(attr)%4 != 0 {
+		attr = append(attr, 0)
+	}
+	return attr
+}
+
+// 編碼 (XOR-)MAPPED-ADDRESS 的值
+func stunAddrValue(addr netip.Addr, xor bool, transactionID [12]byte) []byte {
+	ip := addr.AsSlice()
+	value := []byte{0, 0x01, 0x12, 0x34}
+	if addr.Is6() {
+		value[1] = 0x02
+	}
+	if xor {
+		var key [16]byte
+		binary.BigEndian.PutUint32(key[0:], stunMagicCookie)
+		copy(key[4:], transactionID[:])
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+	return append(value, ip...)
+}
+
+func TestParseSTUNResponse(t *testing.T) {
+	xorMapped := stunAttr(stunAttrXORMappedAddress, stunAddrValue(netip.MustParseAddr("5.6.7.9"), true, testTransactionID))
+	mapped := stunAttr(stunAttrMappedAddress, stunAddrValue(netip.MustParseAddr("5.6.7.8"), false, testTransactionID))
+	xorMapped6 := stunAttr(stunAttrXORMappedAddress, stunAddrValue(netip.MustParseAddr("2001:db8::1"), true, testTransactionID))
+
+	otherID := testTransactionID
+	otherID[0] = 0xff
+
+	tests := []struct {
+		name     string
+		msg      []byte
+		want     string
+		mismatch bool
+		wantErr  bool
+	}{
+		{
+			name: "XOR-MAPPED-ADDRESS",
+			msg:  stunMessage(stunBindingResponse, len(xorMapped), testTransactionID, xorMapped),
+			want: "5.6.7.9",
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS IPv6",
+			msg:  stunMessage(stunBindingResponse, len(xorMapped6), testTransactionID, xorMapped6),
+			want: "2001:db8::1",
+		},
+		{
+			name: "MAPPED-ADDRESS",
+			msg:  stunMessage(stunBindingResponse, len(mapped), testTransactionID, mapped),
+			want: "5.6.7.8",
+		},
+		{
+			name: "優先使用 XOR-MAPPED-ADDRESS",
+			msg:  stunMessage(stunBindingResponse, len(mapped)+len(xorMapped), testTransactionID, append(append([]byte{}, mapped...), xorMapped...)),
+			want: "5.6.7.9",
+		},
+		{
+			name:     "交易 ID 不符",
+			msg:      stunMessage(stunBindingResponse, len(xorMapped), otherID, xorMapped),
+			mismatch: true,
+		},
+		{
+			name:     "標頭不完整",
+			msg:      stunMessage(stunBindingResponse, 0, testTransactionID, nil)[:12],
+			mismatch: true,
+		},
+		{
+			name:    "綁定錯誤",
+			msg:     stunMessage(stunBindingError, 0, testTransactionID, nil),
+			wantErr: true,
+		},
+		{
+			name:    "訊息被截斷",
+			msg:     stunMessage(stunBindingResponse, len(xorMapped), testTransactionID, xorMapped)[:stunHeaderSize+8],
+			wantErr: true,
+		},
+		{
+			name:    "訊息長度未對齊",
+			msg:     stunMessage(stunBindingResponse, 6, testTransactionID, []byte{0, 0x80, 0, 2, 0, 0}),
+			wantErr: true,
+		},
+		{
+			name:    "屬性長度超出訊息",
+			msg:     stunMessage(stunBindingResponse, 8, testTransactionID, []byte{0, 0x20, 0, 12, 0, 0, 0, 0}),
+			wantErr: true,
+		},
+		{
+			name:    "沒有映射地址",
+			msg:     stunMessage(stunBindingResponse, 8, testTransactionID, stunAttr(0x8022, []byte("abc"))),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := parseSTUNResponse(tt.msg, testTransactionID)
+			switch {
+			case tt.mismatch:
+				if !errors.Is(err, errSTUNMismatch) {
+					t.Fatalf("預期 errSTUNMismatch，實際 %v", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, errSTUNMismatch) {
+					t.Fatalf("預期錯誤，實際 %v, %v", addr, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("解析失敗: %v", err)
+				}
+				if addr.String() != tt.want {
+					t.Fatalf("預期 %s，實際 %s", tt.want, addr)
+				}
+			}
+		})
+	}
+}
+
+// 本地 STUN 回應器：忽略前 drop 個請求，並在正確回應前先送出交易 ID 不符的回應
+func startSTUNResponder(t *testing.T, drop int) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("監聽失敗: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for received := 0; ; received++ {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < stunHeaderSize || received < drop {
+				continue
+			}
+			transactionID := [12]byte(buf[8:20])
+
+			otherID := transactionID
+			otherID[0] ^= 0xff
+			stale := stunAttr(stunAttrXORMappedAddress, stunAddrValue(netip.MustParseAddr("1.1.1.1"), true, otherID))
+			conn.WriteTo(stunMessage(stunBindingResponse, len(stale), otherID, stale), peer)
+
+			attr := stunAttr(stunAttrXORMappedAddress, stunAddrValue(netip.MustParseAddr("5.6.7.9"), true, transactionID))
+			conn.WriteTo(stunMessage(stunBindingResponse, len(attr), transactionID, attr), peer)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestSTUNProviderGetIP(t *testing.T) {
+	for _, drop := range []int{0, 1} {
+		server := startSTUNResponder(t, drop)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		ip, err := stunProvider{server: server}.GetIP(ctx, familyIPv4)
+		if err != nil {
+			t.Fatalf("忽略 %d 個請求: %v", drop, err)
+		}
+		if ip != "5.6.7.9" {
+			t.Fatalf("忽略 %d 個請求: 預期 5.6.7.9，實際 %s", drop, ip)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// NAT-PMP (RFC 6886) 和 PCP (RFC 6887) 共用路由器的 5351 埠
+const natpmpPort = 5351
+
+const (
+	natpmpInitialTimeout = 250 * time.Millisecond // 首次等待回應的時間，之後每次加倍
+	natpmpAttempts       = 4
+)
+
+const (
+	pcpVersion    = 2
+	pcpOpcodeMap  = 1
+	pcpResponse   = 0x80
+	pcpLifetime   = 60 // 查詢用的臨時映射存活時間(秒)，查詢後立即刪除
+	pcpHeaderSize = 24
+	pcpMapSize    = 36
+)
+
+// 以 NAT-PMP 查詢路由器的外部地址
+func natpmpExternalIP(ctx context.Context, gateway netip.AddrPort) (netip.Addr, error) {
+	response, err := natpmpExchange(ctx, gateway, []byte{0, 0}, func(resp []byte) bool {
+		return len(resp) >= 12 && resp[0] == 0 && resp[1] == 128
+	})
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if code := binary.BigEndian.Uint16(response[2:]); code != 0 {
+		return netip.Addr{}, fmt.Errorf("NAT-PMP 回應錯誤碼 %d", code)
+	}
+	return netip.AddrFrom4([4]byte(response[8:12])), nil
+}
+
+// 以 PCP 建立臨時的 UDP 映射，從回應中取得外部地址，之後刪除映射
+func pcpExternalIP(ctx context.Context, gateway netip.AddrPort) (netip.Addr, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", gateway.String())
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("連線路由器失敗: %w", err)
+	}
+	local := conn.LocalAddr().(*net.UDPAddr)
+	conn.Close()
+
+	clientIP, ok := netip.AddrFromSlice(local.IP.To4())
+	if !ok {
+		return netip.Addr{}, errors.New("無法取得本機地址")
+	}
+
+	var nonce [12]byte
+	rand.Read(nonce[:])
+	request := pcpMapRequest(clientIP, uint16(local.Port), nonce, pcpLifetime)
+
+	response, err := natpmpExchange(ctx, gateway, request, func(resp []byte) bool {
+		return len(resp) >= pcpHeaderSize+pcpMapSize &&
+			resp[1] == pcpResponse|pcpOpcodeMap &&
+			[12]byte(resp[pcpHeaderSize:pcpHeaderSize+12]) == nonce
+	})
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if code := response[3]; code != 0 {
+		return netip.Addr{}, fmt.Errorf("PCP 回應錯誤碼 %d", code)
+	}
+
+	// 刪除剛建立的映射（存活時間為 0），失敗時映射也會在存活時間後過期
+	deleteRequest := pcpMapRequest(clientIP, uint16(local.Port), nonce, 0)
+	natpmpExchange(ctx, gateway, deleteRequest, func([]byte) bool { return true })
+
+	external := netip.AddrFrom16([16]byte(response[pcpHeaderSize+20 : pcpHeaderSize+36])).Unmap()
+	if !external.Is4() {
+		return netip.Addr{}, fmt.Errorf("PCP 回傳的外部地址無效: %s", external)
+	}
+	return external, nil
+}
+
+// 建立 PCP MAP 請求：標頭 24 位元組，MAP 資料 36 位元組
+func pcpMapRequest(clientIP netip.Addr, internalPort uint16, nonce [12]byte, lifetime uint32) []byte {
+	request := make([]byte, pcpHeaderSize+pcpMapSize)
+	request[0] = pcpVersion
+	request[1] = pcpOpcodeMap
+	binary.BigEndian.PutUint32(request[4:], lifetime)
+	client := netip.AddrFrom16(clientIP.As16()).As16()
+	copy(request[8:24], client[:])
+
+	data := request[pcpHeaderSize:]
+	copy(data[0:12], nonce[:])
+	data[12] = 17 // UDP
+	binary.BigEndian.PutUint16(data[16:], internalPort)
+	// 建議的外部埠為 0（不指定），建議的外部地址為 ::ffff:0.0.0.0（任意 IPv4）
+	data[30], data[31] = 0xff, 0xff
+	return request
+}
+
+// 發送請求並等待符合條件的回應，逾時時以加倍的等待時間重送
+func natpmpExchange(ctx context.Context, gateway netip.AddrPort, request []byte, match func([]byte) bool) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", gateway.String())
+	if err != nil {
+		return nil, fmt.Errorf("連線路由器失敗: %w", err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1100)
+	timeout := natpmpInitialTimeout
+	for attempt := 1; attempt <= natpmpAttempts; attempt++ {
+		deadline := time.Now().Add(timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		conn.SetDeadline(deadline)
+
+		if _, err := conn.Write(request); err != nil {
+			return nil, fmt.Errorf("發送請求失敗: %w", err)
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+					break // 重送請求
+				}
+				return nil, fmt.Errorf("路由器沒有回應: %w", err)
+			}
+			if match(buf[:n]) {
+				return buf[:n], nil
+			}
+		}
+		timeout *= 2
+	}
+
+	return nil, errors.New("路由器沒有回應")
+}
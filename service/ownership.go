@@ -0,0 +1,140 @@
+package service
+
+import (
+	"cfddns/cloudflare"
+	"cfddns/config"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// 記錄由其他實例管理時回傳的錯誤，可用 errors.Is 判斷
+var ErrOwnedByOther = errors.New("記錄由其他實例管理")
+
+// 從記錄的備註或標籤解析擁有者，沒有標記時回傳空字串
+func recordOwner(ownership config.OwnershipConfig, comment string, tags []string) string {
+	if ownership.Mode == config.OwnershipTag {
+		for _, tag := range tags {
+			if owner, ok := strings.CutPrefix(tag, config.OwnerTagPrefix); ok {
+				return owner
+			}
+		}
+		return ""
+	}
+
+	if _, rest, ok := strings.Cut(comment, config.OwnerCommentPrefix); ok {
+		owner, _, _ := strings.Cut(rest, " ")
+		return owner
+	}
+	return ""
+}
+
+// 在備註後附加擁有者標記，取代原有的標記
+func stampComment(comment, ownerID string) string {
+	return strings.TrimSpace(config.StripOwnerComment(comment) + " " + config.OwnerCommentPrefix + ownerID)
+}
+
+// 加上擁有者標籤，取代原有的擁有者標籤
+func stampTags(tags []string, ownerID string) []string {
+	stamped := slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+		return strings.HasPrefix(tag, config.OwnerTagPrefix)
+	})
+	return append(stamped, config.OwnerTagPrefix+ownerID)
+}
+
+// 檢查記錄的擁有者，尚未標記為本實例時在修改中加上標記。
+// 記錄由其他實例管理且未設置 takeover 時回傳 ErrOwnedByOther
+func (d *DDNSService) stampOwner(record *config.DNSRecord, current CachedRecord, patch *cloudflare.RecordPatch) error {
+	ownership := d.config.Cloudflare.Ownership
+	if !ownership.Enabled {
+		return nil
+	}
+
+	owner := recordOwner(ownership, current.Comment, current.Tags)
+	if owner == ownership.OwnerID {
+		return nil
+	}
+	if owner != "" && !record.Takeover {
+		return fmt.Errorf("%w: %s 的擁有者為 %s（設置 takeover: true 以接管）", ErrOwnedByOther, record.Name, owner)
+	}
+
+	if ownership.Mode == config.OwnershipTag {
+		patch.Tags = stampTags(current.Tags, ownership.OwnerID)
+	} else {
+		comment := stampComment(current.Comment, ownership.OwnerID)
+		patch.Comment = &comment
+	}
+	return nil
+}
+
+// 修改中是否包含擁有者標記
+func stampsOwner(patch cloudflare.RecordPatch) bool {
+	return patch.Comment != nil || patch.Tags != nil
+}
+
+// 創建記錄時寫入的備註和標籤：match 的條件（之後才能找到這筆記錄）和擁有者標記
+func (d *DDNSService) newRecordLabels(record *config.DNSRecord) (string, []string) {
+	comment := record.Match.Comment
+	var tags []string
+	if record.Match.Tag != "" {
+		tags = append(tags, record.Match.Tag)
+	}
+
+	if ownership := d.config.Cloudflare.Ownership; ownership.Enabled {
+		if ownership.Mode == config.OwnershipTag {
+			tags = stampTags(tags, ownership.OwnerID)
+		} else {
+			comment = stampComment(comment, ownership.OwnerID)
+		}
+	}
+
+	return comment, tags
+}
+
+// 啟用擁有者標記時，每個區域以一次列出記錄的請求更新暫存中的備註和標籤，
+// 之後的批次和逐筆修改即可依據目前的擁有者判斷，不需要逐筆查詢。
+// 無法確認的記錄會從暫存移除，逐筆更新時重新查詢
+func (d *DDNSService) refreshOwners(ctx context.Context, records []config.DNSRecord) {
+	if !d.config.Cloudflare.Ownership.Enabled {
+		return
+	}
+
+	var zones []string
+	groups := make(map[string][]config.DNSRecord)
+	for _, record := range records {
+		current, ok := d.cachedRecord(&record)
+		if !ok || current.ID == "" || current.ZoneID == "" {
+			continue
+		}
+		if _, seen := groups[current.ZoneID]; !seen {
+			zones = append(zones, current.ZoneID)
+		}
+		groups[current.ZoneID] = append(groups[current.ZoneID], record)
+	}
+
+	for _, zoneID := range zones {
+		cfRecords, err := d.cfClient.ListDNSRecords(ctx, zoneID, "", "")
+		if err != nil && verbose {
+			fmt.Printf("⚠️  列出區域 %s 的記錄失敗，改為逐筆確認擁有者: %v\n", zoneID, err)
+		}
+
+		byID := make(map[string]*cloudflare.DNSRecord, len(cfRecords))
+		for i := range cfRecords {
+			if cfRecords[i].ZoneID == "" {
+				cfRecords[i].ZoneID = zoneID
+			}
+			byID[cfRecords[i].ID] = &cfRecords[i]
+		}
+
+		for _, record := range groups[zoneID] {
+			current, _ := d.cachedRecord(&record)
+			if cfRecord, ok := byID[current.ID]; ok {
+				d.storeRecord(&record, cfRecord)
+			} else {
+				d.forgetRecord(recordKey(&record))
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"cfddns/cloudflare"
+	"cfddns/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 計劃中的操作
+type PlanAction string
+
+const (
+	PlanCreate PlanAction = "create" // 記錄不存在，將會創建
+	PlanUpdate PlanAction = "update" // 記錄內容不同步，將會更新
+	PlanNoop   PlanAction = "noop"   // 記錄已同步，不需要操作
+	PlanSkip   PlanAction = "skip"   // 記錄不存在且未啟用 create_if_missing
+	PlanError  PlanAction = "error"  // 無法判斷（獲取 IP 或記錄失敗）
+)
+
+// 記錄的狀態
+type RecordState struct {
+	Content string `json:"content"`
+	Proxied bool   `json:"proxied"`
+	TTL     int    `json:"ttl"`
+	Owner   string `json:"owner,omitempty"` // 擁有者標記（啟用 ownership 時）
+}
+
+// 單一記錄的計劃
+type RecordPlan struct {
+	Name    string       `json:"name"`
+	Type    string       `json:"type"`
+	Match   string       `json:"match,omitempty"` // 選擇記錄的條件
+	Action  PlanAction   `json:"action"`
+	Current *RecordState `json:"current,omitempty"` // Cloudflare 中的狀態
+	Desired *RecordState `json:"desired,omitempty"` // 配置期望的狀態
+	Warning string       `json:"warning,omitempty"` // drift=warn 時未修正的設定差異
+	Error   string       `json:"error,omitempty"`
+}
+
+// 變更內容的描述，例如 "1.1.1.1 → 5.6.7.8, TTL 自動 → 300"
+func (p RecordPlan) Diff() string {
+	switch {
+	case p.Error != "":
+		return p.Error
+	case p.Desired == nil:
+		return ""
+	case p.Current == nil:
+		return fmt.Sprintf("%s (proxied=%v, TTL %s)", p.Desired.Content, p.Desired.Proxied, formatPlanTTL(p.Desired.TTL))
+	}
+
+	var diffs []string
+	if !SameIP(p.Current.Content, p.Desired.Content) {
+		diffs = append(diffs, fmt.Sprintf("%s → %s", p.Current.Content, p.Desired.Content))
+	}
+	if p.Current.Proxied != p.Desired.Proxied {
+		diffs = append(diffs, fmt.Sprintf("proxied %v → %v", p.Current.Proxied, p.Desired.Proxied))
+	}
+	if p.Current.TTL != p.Desired.TTL {
+		diffs = append(diffs, fmt.Sprintf("TTL %s → %s", formatPlanTTL(p.Current.TTL), formatPlanTTL(p.Desired.TTL)))
+	}
+	if p.Current.Owner != p.Desired.Owner {
+		diffs = append(diffs, fmt.Sprintf("擁有者 %s → %s", formatPlanOwner(p.Current.Owner), p.Desired.Owner))
+	}
+	if len(diffs) == 0 {
+		diffs = append(diffs, p.Current.Content)
+	}
+	if p.Warning != "" {
+		diffs = append(diffs, "⚠️ "+p.Warning)
+	}
+	return strings.Join(diffs, ", ")
+}
+
+func formatPlanTTL(ttl int) string {
+	if ttl == 1 {
+		return "自動"
+	}
+	return fmt.Sprintf("%d", ttl)
+}
+
+func formatPlanOwner(owner string) string {
+	if owner == "" {
+		return "無"
+	}
+	return owner
+}
+
+// 完整的計劃
+type Plan struct {
+	IPs     map[string]string `json:"ips"` // 記錄類型 -> 當前公共 IP
+	Records []RecordPlan      `json:"records"`
+}
+
+// 統計各操作的數量
+func (p *Plan) Count(action PlanAction) int {
+	count := 0
+	for _, record := range p.Records {
+		if record.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// 計算所有記錄的計劃，不修改任何記錄
+func (d *DDNSService) Plan(ctx context.Context) *Plan {
+	ctx, cancel := context.WithTimeout(ctx, d.cycleTimeout())
+	defer cancel()
+
+	return d.planRecords(ctx, d.config.DNSRecords)
+}
+
+// 計算指定記錄的計劃
+func (d *DDNSService) planRecords(ctx context.Context, records []config.DNSRecord) *Plan {
+	plan := &Plan{IPs: make(map[string]string)}
+	ipErrors := make(map[string]error)
+
+	for _, family := range d.activeFamilies() {
+		ip, err := d.lookupIP(ctx, family, false)
+		if err != nil {
+			ipErrors[family.recordType] = err
+			continue
+		}
+		plan.IPs[family.recordType] = ip
+	}
+
+	plan.Records = forEachConcurrent(d.concurrency(), records, func(_ io.Writer, record config.DNSRecord) RecordPlan {
+		if err, failed := ipErrors[record.Type]; failed {
+			return RecordPlan{
+				Name:   record.Name,
+				Type:   record.Type,
+				Match:  record.Match.String(),
+				Action: PlanError,
+				Error:  fmt.Sprintf("獲取當前 IP 失敗: %v", err),
+			}
+		}
+		return d.planRecord(ctx, &record, plan.IPs[record.Type])
+	})
+
+	return plan
+}
+
+// 計算單一記錄的計劃，與 updateSingleRecord 的判斷一致
+func (d *DDNSService) planRecord(ctx context.Context, record *config.DNSRecord, currentIP string) RecordPlan {
+	plan := RecordPlan{
+		Name:  record.Name,
+		Type:  record.Type,
+		Match: record.Match.String(),
+		Desired: &RecordState{
+			Content: currentIP,
+			Proxied: record.Proxied,
+			TTL:     cloudflare.EffectiveTTL(record),
+		},
+	}
+
+	if ownership := d.config.Cloudflare.Ownership; ownership.Enabled {
+		plan.Desired.Owner = ownership.OwnerID
+	}
+
+	cfRecord, err := d.cfClient.GetDNSRecord(ctx, record)
+	if errors.Is(err, cloudflare.ErrRecordNotFound) {
+		if record.CreateIfMissing {
+			plan.Action = PlanCreate
+		} else {
+			plan.Action = PlanSkip
+			plan.Error = "記錄不存在（未啟用 create_if_missing）"
+		}
+		return plan
+	}
+	if err != nil {
+		plan.Action = PlanError
+		plan.Error = fmt.Sprintf("獲取記錄失敗: %v", err)
+		return plan
+	}
+
+	plan.Current = &RecordState{
+		Content: cfRecord.Content,
+		Proxied: cfRecord.Proxied,
+		TTL:     cfRecord.TTL,
+	}
+
+	current := CachedRecord{
+		Content: cfRecord.Content,
+		Proxied: cfRecord.Proxied,
+		TTL:     cfRecord.TTL,
+		Comment: cfRecord.Comment,
+		Tags:    cfRecord.Tags,
+	}
+	patch, drift, err := d.recordPatch(record, current, currentIP)
+	if err != nil {
+		plan.Action = PlanError
+		plan.Error = err.Error()
+		return plan
+	}
+
+	// drift 為 warn / ignore 時不會修改 proxied / ttl
+	if !enforceDrift(record) {
+		plan.Desired.Proxied = cfRecord.Proxied
+		plan.Desired.TTL = cfRecord.TTL
+		if record.Drift == config.DriftWarn && len(drift) > 0 {
+			plan.Warning = "drift=warn 不修正: " + strings.Join(drift, ", ")
+		}
+	}
+
+	if ownership := d.config.Cloudflare.Ownership; ownership.Enabled {
+		plan.Current.Owner = recordOwner(ownership, cfRecord.Comment, cfRecord.Tags)
+	}
+
+	if patch.Empty() {
+		plan.Action = PlanNoop
+	} else {
+		plan.Action = PlanUpdate
+	}
+
+	return plan
+}
+
+// dry-run 模式的檢查：只顯示將要執行的操作，不修改記錄、不更新暫存
+func (d *DDNSService) dryRunCheck(ctx context.Context) (CheckResult, error) {
+	plan := d.planRecords(ctx, d.config.DNSRecords)
+	printPlan(plan)
+
+	result := CheckResult{
+		Total:   len(plan.Records),
+		Updated: plan.Count(PlanCreate) + plan.Count(PlanUpdate),
+		Failed:  plan.Count(PlanError),
+	}
+
+	fmt.Printf("📝 [dry-run] 檢查完成: %d 個將創建, %d 個將更新, %d 個已同步",
+		plan.Count(PlanCreate), plan.Count(PlanUpdate), plan.Count(PlanNoop))
+	d.printNextCheck()
+
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%d 個記錄無法判斷", result.Failed)
+	}
+	return result, nil
+}
+
+// 顯示計劃（dry-run 模式的日誌）
+func printPlan(plan *Plan) {
+	for _, record := range plan.Records {
+		switch record.Action {
+		case PlanCreate:
+			fmt.Printf("📝 [dry-run] 將創建 %s (%s): %s\n", record.Name, record.Type, record.Diff())
+		case PlanUpdate:
+			fmt.Printf("📝 [dry-run] 將更新 %s (%s): %s\n", record.Name, record.Type, record.Diff())
+		case PlanSkip:
+			fmt.Printf("⚠️  [dry-run] 略過 %s (%s): %s\n", record.Name, record.Type, record.Error)
+		case PlanError:
+			fmt.Printf("❌ [dry-run] %s (%s): %s\n", record.Name, record.Type, record.Error)
+		default:
+			if record.Warning != "" {
+				fmt.Printf("⚠️  [dry-run] %s (%s): %s\n", record.Name, record.Type, record.Warning)
+			} else if verbose {
+				fmt.Printf("✅ [dry-run] %s (%s) 已同步: %s\n", record.Name, record.Type, record.Diff())
+			}
+		}
+	}
+}
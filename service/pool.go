@@ -0,0 +1,61 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// 以有限的並發數處理每個項目，結果依輸入順序回傳。
+// 每個項目的輸出先寫入各自的緩衝區，再依輸入順序顯示，避免多個記錄的日誌交錯
+func forEachConcurrent[T, R any](limit int, items []T, fn func(out io.Writer, item T) R) []R {
+	results := make([]R, len(items))
+
+	// 不並發時直接輸出，保持原本的行為
+	if limit <= 1 || len(items) <= 1 {
+		for i, item := range items {
+			results[i] = fn(os.Stdout, item)
+		}
+		return results
+	}
+
+	outputs := make([]bytes.Buffer, len(items))
+	done := make(chan int, len(items))
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = fn(&outputs[i], item)
+			done <- i
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// 前面的項目都完成後才顯示，維持輸入順序
+	finished := make([]bool, len(items))
+	next := 0
+	for i := range done {
+		finished[i] = true
+		for next < len(items) && finished[next] {
+			outputs[next].WriteTo(os.Stdout)
+			next++
+		}
+	}
+
+	return results
+}
+
+// 檢查時的並發數
+func (d *DDNSService) concurrency() int {
+	return max(d.config.Global.Concurrency, 1)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddr        = "239.255.255.250:1900"
+	ssdpSearchType  = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	ssdpWaitTimeout = 2 * time.Second
+	upnpTimeout     = 5 * time.Second
+)
+
+// 提供 GetExternalIPAddress 的 WAN 連線服務
+var upnpWANServices = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:",
+	"urn:schemas-upnp-org:service:WANPPPConnection:",
+}
+
+// 區域網絡內的請求不經過代理
+var upnpClient = &http.Client{
+	Timeout:   upnpTimeout,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+// 以 SSDP 發現 UPnP IGD 路由器，並呼叫 GetExternalIPAddress
+func upnpExternalIP(ctx context.Context) (netip.Addr, error) {
+	locations, err := ssdpDiscover(ctx, ssdpAddr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	var lastErr error
+	for _, location := range locations {
+		addr, err := upnpGetExternalIP(ctx, location)
+		if err == nil {
+			return addr, nil
+		}
+		lastErr = err
+	}
+	return netip.Addr{}, lastErr
+}
+
+// 發送 SSDP M-SEARCH 請求，回傳回應的裝置描述網址
+func ssdpDiscover(ctx context.Context, target string) ([]string, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("建立 SSDP 連線失敗: %w", err)
+	}
+	defer conn.Close()
+
+	dst, err := net.ResolveUDPAddr("udp4", target)
+	if err != nil {
+		return nil, err
+	}
+
+	request := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n" +
+		"ST: " + ssdpSearchType + "\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(request), dst); err != nil {
+		return nil, fmt.Errorf("發送 SSDP 請求失敗: %w", err)
+	}
+
+	deadline := time.Now().Add(ssdpWaitTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	// 收集等待時間內的所有回應（網絡中可能有多個 UPnP 裝置）
+	var locations []string
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if location := resp.Header.Get("Location"); location != "" && !slices.Contains(locations, location) {
+			locations = append(locations, location)
+		}
+	}
+
+	if len(locations) == 0 {
+		return nil, errors.New("找不到 UPnP 路由器")
+	}
+	return locations, nil
+}
+
+// UPnP 裝置描述
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+type upnpDevice struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// 遞迴尋找 WAN 連線服務
+func (d upnpDevice) wanService() (upnpService, bool) {
+	for _, service := range d.Services {
+		for _, prefix := range upnpWANServices {
+			if strings.HasPrefix(service.ServiceType, prefix) {
+				return service, true
+			}
+		}
+	}
+	for _, device := range d.Devices {
+		if service, ok := device.wanService(); ok {
+			return service, true
+		}
+	}
+	return upnpService{}, false
+}
+
+// 讀取裝置描述並呼叫 WAN 連線服務的 GetExternalIPAddress
+func upnpGetExternalIP(ctx context.Context, location string) (netip.Addr, error) {
+	base, err := url.Parse(location)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("裝置描述網址無效: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	resp, err := upnpClient.Do(req)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("讀取裝置描述失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var root upnpRoot
+	if err := xml.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return netip.Addr{}, fmt.Errorf("解析裝置描述失敗: %w", err)
+	}
+
+	service, ok := root.Device.wanService()
+	if !ok {
+		return netip.Addr{}, errors.New("路由器沒有 WAN 連線服務")
+	}
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return netip.Addr{}, fmt.Errorf("裝置描述的 URLBase 無效: %w", err)
+		}
+	}
+	controlURL, err := base.Parse(service.ControlURL)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("控制網址無效: %w", err)
+	}
+
+	return upnpSOAPExternalIP(ctx, controlURL.String(), service.ServiceType)
+}
+
+// GetExternalIPAddress 的 SOAP 回應
+type upnpExternalIPResponse struct {
+	Body struct {
+		Response struct {
+			IP string `xml:"NewExternalIPAddress"`
+		} `xml:"GetExternalIPAddressResponse"`
+	} `xml:"Body"`
+}
+
+// 呼叫 GetExternalIPAddress
+func upnpSOAPExternalIP(ctx context.Context, controlURL, serviceType string) (netip.Addr, error) {
+	body := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:GetExternalIPAddress xmlns:u="` + serviceType + `"></u:GetExternalIPAddress></s:Body>` +
+		`</s:Envelope>`
+
+	req, err := http.NewRequestWithContext(ctx, "POST", controlURL, strings.NewReader(body))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+serviceType+`#GetExternalIPAddress"`)
+
+	resp, err := upnpClient.Do(req)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("呼叫 GetExternalIPAddress 失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("讀取回應失敗: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("GetExternalIPAddress 回應狀態碼 %d", resp.StatusCode)
+	}
+
+	var result upnpExternalIPResponse
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return netip.Addr{}, fmt.Errorf("解析回應失敗: %w", err)
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(result.Body.Response.IP))
+	if err != nil || !addr.Is4() {
+		return netip.Addr{}, fmt.Errorf("路由器回傳的 WAN 地址無效: %q", result.Body.Response.IP)
+	}
+	return addr, nil
+}
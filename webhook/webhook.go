@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -49,7 +50,7 @@ func NewClient(url, chatID, hookType, template string, enabled, onSuccess, onFai
 	}
 }
 
-func (w *WebhookClient) SendSuccess(DNSip, ip, recordName string) error {
+func (w *WebhookClient) SendSuccess(ctx context.Context, DNSip, ip, recordName string) error {
 	if !w.enabled || !w.onSuccess {
 		return nil
 	}
@@ -63,10 +64,10 @@ func (w *WebhookClient) SendSuccess(DNSip, ip, recordName string) error {
 	details := fmt.Sprintf("%s → %s\n時間: %s",
 		DNSip, ip, time.Now().Format("2006-01-02 15:04:05"))
 
-	return w.sendMessage(title, message, details, "success")
+	return w.sendMessage(ctx, title, message, details, "success")
 }
 
-func (w *WebhookClient) SendFailure(recordName, errorMsg string) error {
+func (w *WebhookClient) SendFailure(ctx context.Context, recordName, errorMsg string) error {
 	if !w.enabled || !w.onFailure {
 		return nil
 	}
@@ -76,10 +77,10 @@ func (w *WebhookClient) SendFailure(recordName, errorMsg string) error {
 	details := fmt.Sprintf("記錄名稱: %s\n錯誤信息: %s\n時間: %s",
 		recordName, errorMsg, time.Now().Format("2006-01-02 15:04:05"))
 
-	return w.sendMessage(title, message, details, "error")
+	return w.sendMessage(ctx, title, message, details, "error")
 }
 
-func (w *WebhookClient) SendInfo(customMessage string) error {
+func (w *WebhookClient) SendInfo(ctx context.Context, customMessage string) error {
 	if !w.enabled {
 		return nil
 	}
@@ -88,19 +89,31 @@ func (w *WebhookClient) SendInfo(customMessage string) error {
 	message := customMessage
 	details := fmt.Sprintf("時間: %s", time.Now().Format("2006-01-02 15:04:05"))
 
-	return w.sendMessage(title, message, details, "info")
+	return w.sendMessage(ctx, title, message, details, "info")
 }
 
-func (w *WebhookClient) SendCustom(title, message, level string) error {
+func (w *WebhookClient) SendWarning(ctx context.Context, customMessage string) error {
 	if !w.enabled {
 		return nil
 	}
 
+	title := "⚠️ DDNS 警告"
+	message := customMessage
 	details := fmt.Sprintf("時間: %s", time.Now().Format("2006-01-02 15:04:05"))
-	return w.sendMessage(title, message, details, level)
+
+	return w.sendMessage(ctx, title, message, details, "warning")
 }
 
-func (w *WebhookClient) SendTest() error {
+func (w *WebhookClient) SendCustom(ctx context.Context, title, message, level string) error {
+	if !w.enabled {
+		return nil
+	}
+
+	details := fmt.Sprintf("時間: %s", time.Now().Format("2006-01-02 15:04:05"))
+	return w.sendMessage(ctx, title, message, details, level)
+}
+
+func (w *WebhookClient) SendTest(ctx context.Context) error {
 	if !w.enabled {
 		return nil
 	}
@@ -110,19 +123,19 @@ func (w *WebhookClient) SendTest() error {
 	details := fmt.Sprintf("服務: Cloudflare DDNS\n類型: %s\n時間: %s",
 		w.hookType, time.Now().Format("2006-01-02 15:04:05"))
 
-	return w.sendMessage(title, message, details, "info")
+	return w.sendMessage(ctx, title, message, details, "info")
 }
 
-func (w *WebhookClient) sendMessage(title, message, details, level string) error {
+func (w *WebhookClient) sendMessage(ctx context.Context, title, message, details, level string) error {
 	switch w.hookType {
 	case "telegram":
-		return w.sendTelegramMessage(title, message, details, level)
+		return w.sendTelegramMessage(ctx, title, message, details, level)
 	default:
-		return w.sendGenericMessage(title, message, details, level)
+		return w.sendGenericMessage(ctx, title, message, details, level)
 	}
 }
 
-func (w *WebhookClient) sendGenericMessage(title, message, details, level string) error {
+func (w *WebhookClient) sendGenericMessage(ctx context.Context, title, message, details, level string) error {
 	webhookMsg := WebhookMessage{
 		Title:     title,
 		Message:   message + "\n" + details,
@@ -135,7 +148,7 @@ func (w *WebhookClient) sendGenericMessage(title, message, details, level string
 		return err
 	}
 
-	resp, err := w.client.Post(w.url, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := w.post(ctx, jsonData)
 	if err != nil {
 		return err
 	}
@@ -148,7 +161,7 @@ func (w *WebhookClient) sendGenericMessage(title, message, details, level string
 	return nil
 }
 
-func (w *WebhookClient) sendTelegramMessage(title, message, details, level string) error {
+func (w *WebhookClient) sendTelegramMessage(ctx context.Context, title, message, details, level string) error {
 	// 根據模闆類型構建消息內容
 	var text string
 	var parseMode string
@@ -178,7 +191,7 @@ func (w *WebhookClient) sendTelegramMessage(title, message, details, level strin
 		return err
 	}
 
-	resp, err := w.client.Post(w.url, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := w.post(ctx, jsonData)
 	if err != nil {
 		return err
 	}
@@ -193,6 +206,16 @@ func (w *WebhookClient) sendTelegramMessage(title, message, details, level strin
 	return nil
 }
 
+// 發送 JSON 請求
+func (w *WebhookClient) post(ctx context.Context, jsonData []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return w.client.Do(req)
+}
+
 // 純文本不需要轉義，但為了安全起見還是保留
 func escapeText(text string) string {
 	// 純文本情況下，隻需要處理可能破壞格式的字符